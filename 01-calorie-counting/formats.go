@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	InputFormatPuzzle = "puzzle"
+	InputFormatCSV    = "csv"
+	InputFormatJSON   = "json"
+)
+
+// Reads a calorie inventory, every reader produces elves in input order with
+// List[i].Id == i
+type ElfsReader func(r io.Reader) (Elfs, error)
+
+var ElfsReaders = map[string]ElfsReader{
+	InputFormatPuzzle: readPuzzleFormat,
+	InputFormatCSV:    readCSVFormat,
+	InputFormatJSON:   readJSONFormat,
+}
+
+// Format given by the file extension, the puzzle format when unknown
+func inputFormatFromPath(filePath string) string {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".csv":
+		return InputFormatCSV
+	case ".json":
+		return InputFormatJSON
+	default:
+		return InputFormatPuzzle
+	}
+}
+
+func readPuzzleFormat(r io.Reader) (Elfs, error) {
+	rawBytes, err := ioutil.ReadAll(r)
+	if err != nil {
+		return Elfs{}, err
+	}
+	return linesToElfs(strings.Split(string(rawBytes), "\n"))
+}
+
+// One item per row, `elf,item,calories`, with an optional header. Rows of the
+// same elf do not need to be contiguous, elves are ordered by first appearance
+// and get no line range
+func readCSVFormat(r io.Reader) (Elfs, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+
+	var result []Elf
+	elfIndexes := make(map[string]int)
+
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Elfs{}, err
+		}
+
+		calorie, err := strconv.Atoi(strings.TrimSpace(record[2]))
+		if err != nil {
+			if row == 1 && strings.EqualFold(strings.TrimSpace(record[2]), "calories") {
+				continue
+			}
+			return Elfs{}, fmt.Errorf("row %d: invalid calories %q", row, record[2])
+		}
+
+		name := strings.TrimSpace(record[0])
+		index, ok := elfIndexes[name]
+		if !ok {
+			index = len(result)
+			elfIndexes[name] = index
+			result = append(result, Elf{Id: index, Name: name})
+		}
+
+		result[index] = result[index].AddCalorie(calorie)
+	}
+
+	return Elfs{List: result}, nil
+}
+
+type jsonElf struct {
+	Name     string `json:"name"`
+	Calories []int  `json:"calories"`
+}
+
+// An array of elves, `[{"name": "...", "calories": [1000, 2000]}, ...]`
+func readJSONFormat(r io.Reader) (Elfs, error) {
+	var elves []jsonElf
+	if err := json.NewDecoder(r).Decode(&elves); err != nil {
+		return Elfs{}, err
+	}
+
+	result := make([]Elf, len(elves))
+	for i, e := range elves {
+		result[i] = Elf{Id: i, Name: e.Name, Calories: e.Calories}
+	}
+
+	return Elfs{List: result}, nil
+}
+
+// Writes the elves in the blank line separated puzzle format, reading the
+// output back gives the same elves (without their names)
+func WritePuzzleFormat(w io.Writer, elfs Elfs) error {
+	var lines []string
+	for i, elf := range elfs.List {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		for _, c := range elf.Calories {
+			lines = append(lines, strconv.Itoa(c))
+		}
+	}
+
+	_, err := io.WriteString(w, strings.Join(lines, "\n"))
+	return err
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Mutable view over the elves that keeps them ranked by total calories
+// (ties by id, as in Elfs.FindElvesThatCarryMostCalories) after every change,
+// so top and rank queries never need to sort all elves again
+type Inventory struct {
+	elves   map[int]Elf
+	totals  map[int]int
+	nextId  int
+	ranking rankingTree
+}
+
+func NewInventory(elfs Elfs) *Inventory {
+	inv := &Inventory{
+		elves:   make(map[int]Elf, len(elfs.List)),
+		totals:  make(map[int]int, len(elfs.List)),
+		ranking: rankingTree{random: rand.New(rand.NewSource(1))},
+	}
+
+	for _, elf := range elfs.List {
+		inv.elves[elf.Id] = elf
+		inv.totals[elf.Id] = elf.GetTotalCalories()
+		inv.ranking.Insert(elf.Id, inv.totals[elf.Id])
+		if elf.Id >= inv.nextId {
+			inv.nextId = elf.Id + 1
+		}
+	}
+
+	return inv
+}
+
+func (inv *Inventory) Len() int {
+	return len(inv.elves)
+}
+
+func (inv *Inventory) Elf(id int) (Elf, bool) {
+	elf, ok := inv.elves[id]
+	return elf, ok
+}
+
+// Adds an elf carrying nothing and returns its id
+func (inv *Inventory) AddElf() int {
+	id := inv.nextId
+	inv.nextId++
+
+	inv.elves[id] = Elf{Id: id}
+	inv.totals[id] = 0
+	inv.ranking.Insert(id, 0)
+
+	return id
+}
+
+func (inv *Inventory) RemoveElf(id int) error {
+	if _, ok := inv.elves[id]; !ok {
+		return fmt.Errorf("there is no elf %d", id)
+	}
+
+	inv.ranking.Remove(id, inv.totals[id])
+	delete(inv.elves, id)
+	delete(inv.totals, id)
+
+	return nil
+}
+
+func (inv *Inventory) AddItem(id int, calories int) error {
+	elf, ok := inv.elves[id]
+	if !ok {
+		return fmt.Errorf("there is no elf %d", id)
+	}
+
+	inv.elves[id] = elf.AddCalorie(calories)
+	inv.updateTotal(id, inv.totals[id]+calories)
+
+	return nil
+}
+
+// Removes the first item of the elf with the given calories
+func (inv *Inventory) RemoveItem(id int, calories int) error {
+	elf, ok := inv.elves[id]
+	if !ok {
+		return fmt.Errorf("there is no elf %d", id)
+	}
+
+	for i, c := range elf.Calories {
+		if c != calories {
+			continue
+		}
+
+		items := make([]int, 0, len(elf.Calories)-1)
+		items = append(items, elf.Calories[:i]...)
+		items = append(items, elf.Calories[i+1:]...)
+		elf.Calories = items
+
+		inv.elves[id] = elf
+		inv.updateTotal(id, inv.totals[id]-calories)
+		return nil
+	}
+
+	return fmt.Errorf("elf %d carries no item with %d calories", id, calories)
+}
+
+// The elvesCount elves carrying the most calories, heaviest first
+func (inv *Inventory) Top(elvesCount int) []Elf {
+	ids := inv.ranking.First(elvesCount)
+	result := make([]Elf, len(ids))
+	for i, id := range ids {
+		result[i] = inv.elves[id]
+	}
+	return result
+}
+
+// 1-based position of the elf in the ranking
+func (inv *Inventory) Rank(id int) (int, error) {
+	total, ok := inv.totals[id]
+	if !ok {
+		return 0, fmt.Errorf("there is no elf %d", id)
+	}
+	return inv.ranking.CountBefore(id, total) + 1, nil
+}
+
+func (inv *Inventory) updateTotal(id int, total int) {
+	inv.ranking.Remove(id, inv.totals[id])
+	inv.totals[id] = total
+	inv.ranking.Insert(id, total)
+}
+
+// Order statistic tree (treap) of elves, ordered by total calories descending
+// and then by id
+type rankingTree struct {
+	root   *rankingNode
+	random *rand.Rand
+}
+
+type rankingNode struct {
+	id          int
+	total       int
+	priority    int64
+	size        int
+	left, right *rankingNode
+}
+
+func rankedBefore(id int, total int, n *rankingNode) bool {
+	if total != n.total {
+		return total > n.total
+	}
+	return id < n.id
+}
+
+func (n *rankingNode) getSize() int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+func (n *rankingNode) update() {
+	n.size = 1 + n.left.getSize() + n.right.getSize()
+}
+
+func (t *rankingTree) Insert(id int, total int) {
+	node := &rankingNode{id: id, total: total, priority: t.random.Int63(), size: 1}
+	left, right := splitRanking(t.root, id, total)
+	t.root = mergeRanking(mergeRanking(left, node), right)
+}
+
+func (t *rankingTree) Remove(id int, total int) {
+	t.root = removeRanking(t.root, id, total)
+}
+
+// Number of elves ranked before the given one
+func (t *rankingTree) CountBefore(id int, total int) int {
+	count := 0
+	for n := t.root; n != nil; {
+		if rankedBefore(id, total, n) {
+			n = n.left
+		} else {
+			if n.id != id {
+				count++
+			}
+			count += n.left.getSize()
+			n = n.right
+		}
+	}
+	return count
+}
+
+// Ids of the first count elves of the ranking, all of them when count is
+// larger than the ranking
+func (t *rankingTree) First(count int) []int {
+	if count > t.root.getSize() {
+		count = t.root.getSize()
+	}
+	if count < 0 {
+		count = 0
+	}
+	result := make([]int, 0, count)
+
+	var walk func(n *rankingNode)
+	walk = func(n *rankingNode) {
+		if n == nil || len(result) == count {
+			return
+		}
+		walk(n.left)
+		if len(result) < count {
+			result = append(result, n.id)
+		}
+		walk(n.right)
+	}
+	walk(t.root)
+
+	return result
+}
+
+// Splits n in the nodes ranked before (id, total) and the remaining ones
+func splitRanking(n *rankingNode, id int, total int) (*rankingNode, *rankingNode) {
+	if n == nil {
+		return nil, nil
+	}
+	if rankedBefore(id, total, n) {
+		left, right := splitRanking(n.left, id, total)
+		n.left = right
+		n.update()
+		return left, n
+	}
+	left, right := splitRanking(n.right, id, total)
+	n.right = left
+	n.update()
+	return n, right
+}
+
+// Every node of left must be ranked before every node of right
+func mergeRanking(left *rankingNode, right *rankingNode) *rankingNode {
+	if left == nil {
+		return right
+	}
+	if right == nil {
+		return left
+	}
+	if left.priority > right.priority {
+		left.right = mergeRanking(left.right, right)
+		left.update()
+		return left
+	}
+	right.left = mergeRanking(left, right.left)
+	right.update()
+	return right
+}
+
+func removeRanking(n *rankingNode, id int, total int) *rankingNode {
+	if n == nil {
+		return nil
+	}
+	if n.id == id {
+		return mergeRanking(n.left, n.right)
+	}
+	if rankedBefore(id, total, n) {
+		n.left = removeRanking(n.left, id, total)
+	} else {
+		n.right = removeRanking(n.right, id, total)
+	}
+	n.update()
+	return n
+}
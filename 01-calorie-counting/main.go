@@ -3,6 +3,7 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
@@ -14,42 +15,53 @@ import (
 
 func main() {
 	var inputFilePath = flag.String("inputFilePath", "./inputf.txt", "Input File")
+	var redistribute = flag.Bool("redistribute", false, "Plan a redistribution of the food items that balances the elves loads")
+	var redistributeElves = flag.Int("redistributeElves", 0, "Number of elves to redistribute the food items among (0 - same number of elves)")
+	var redistributeMode = flag.String("redistributeMode", RedistributionModeAuto, "Redistribution solver: auto, exact or greedy")
+	var target = flag.Int("target", 0, "Select food items adding up to this number of calories (0 - disabled)")
+	var targetElf = flag.Int("targetElf", -1, "Elf whose items are selected for the target (-1 - all elves items)")
+	var inputFormat = flag.String("inputFormat", "", "Input format: puzzle, csv or json (default - from the file extension)")
+	var convertTo = flag.String("convertTo", "", "Write the inventory in the puzzle format to this file")
+	var interactive = flag.Bool("interactive", false, "Start an interactive session to change the inventory and query the ranking")
 	flag.Parse()
 
 	log.Printf("inputFilePath %v\n", *inputFilePath)
 
+	if *inputFormat == "" {
+		*inputFormat = inputFormatFromPath(*inputFilePath)
+	}
+
 	log.Printf("> (1st Puzzle) Find the Elf carrying the most Calories. How many total Calories is that Elf carrying?")
 
 	start := time.Now()
 
 	log.Println("Start First Solution")
-	elfsMap, err := parseFile(*inputFilePath)
+	elfs, err := parseFile(*inputFilePath, *inputFormat)
 	if err != nil {
 		log.Fatalf("Error Parsing file %v: %v", *inputFilePath, err)
 		return
 	}
 
-	elfs := Elfs{
-		List: elfsMap,
-	}
-
 	elfMostCalories := elfs.FindElfMostCalories()
 
-	log.Printf("The elf with most calories is %v, with %v calories", elfMostCalories.Id, elfMostCalories.GetTotalCalories())
+	log.Printf("The elf with most calories is %v, with %v calories", elfMostCalories.DisplayName(), elfMostCalories.GetTotalCalories())
 
 	elapsed := time.Since(start)
 	log.Printf("End First Solution - %s", elapsed)
 
-	log.Println("Start Second Solution")
-	start = time.Now()
-	part1, err := getMostCalories(*inputFilePath)
-	if err != nil {
-		log.Fatalf("Error Parsing file %v: %v", *inputFilePath, err)
-		return
+	// The second solution streams the puzzle format directly
+	if *inputFormat == InputFormatPuzzle {
+		log.Println("Start Second Solution")
+		start = time.Now()
+		part1, err := getMostCalories(*inputFilePath)
+		if err != nil {
+			log.Fatalf("Error Parsing file %v: %v", *inputFilePath, err)
+			return
+		}
+		log.Printf("The most calories is %v", part1)
+		elapsed = time.Since(start)
+		log.Printf("End Second Solution - %s", elapsed)
 	}
-	log.Printf("The most calories is %v", part1)
-	elapsed = time.Since(start)
-	log.Printf("End Second Solution - %s", elapsed)
 
 	log.Printf("> (2nd Puzzle) Find the top three Elves carrying the most Calories. How many Calories are those Elves carrying in total?")
 
@@ -63,39 +75,144 @@ func main() {
 		totalCalores := v.GetTotalCalories()
 		elvesCarriedCaloriesTotal += totalCalores
 
-		log.Printf("Elf (%d): %d", v.Id, totalCalores)
+		// the csv and json formats have no lines for the items, an elf carrying
+		// nothing has none either
+		if v.StartLine > 0 && v.EndLine >= v.StartLine {
+			log.Printf("Elf (%v) [lines %d-%d]: %d", v.DisplayName(), v.StartLine, v.EndLine, totalCalores)
+		} else {
+			log.Printf("Elf (%v): %d", v.DisplayName(), totalCalores)
+		}
 	}
 
 	log.Printf("Total calories carried by elves: %d", elvesCarriedCaloriesTotal)
+
+	if *convertTo != "" {
+		if err := writeFile(*convertTo, elfs); err != nil {
+			log.Fatalf("Error writing file %v: %v", *convertTo, err)
+			return
+		}
+		log.Printf("Inventory written in the puzzle format to %v", *convertTo)
+	}
+
+	if *redistribute {
+		logRedistributionPlan(elfs, *redistributeElves, *redistributeMode)
+	}
+
+	if *target > 0 {
+		logCalorieSelection(elfs, *target, *targetElf)
+	}
+
+	if *interactive {
+		RunInventoryRepl(NewInventory(elfs), os.Stdin, os.Stdout)
+	}
 }
 
-func parseFile(filePath string) (map[int]Elf, error) {
-	lines, err := getFileLines(filePath)
+func logCalorieSelection(elfs Elfs, target int, elfId int) {
+	log.Printf("> (Rations) Which food items add up to %d calories?", target)
+
+	start := time.Now()
+
+	var selection CalorieSelection
+	var err error
+
+	if elfId < 0 {
+		selection, err = elfs.SelectCaloriesForTarget(target)
+	} else if elfId < len(elfs.List) {
+		selection, err = elfs.List[elfId].SelectCaloriesForTarget(target)
+	} else {
+		err = fmt.Errorf("there is no elf %d", elfId)
+	}
 	if err != nil {
-		return nil, err
+		log.Fatalf("Error selecting food items: %v", err)
+		return
+	}
+
+	for _, it := range selection.Items {
+		log.Printf("Elf (%d) item %d: %d", it.ElfId, it.Index, it.Calories)
 	}
 
-	var result = make(map[int]Elf)
-	elfNumber := 0
-	result[elfNumber] = Elf{Id: elfNumber}
+	log.Printf("Selected %d items with %d calories (target %d, exact: %v)", len(selection.Items), selection.Calories, selection.Target, selection.IsExact())
+	log.Printf("End Rations - %s", time.Since(start))
+}
 
-	for _, line := range lines {
+func logRedistributionPlan(elfs Elfs, elvesCount int, mode string) {
+	log.Printf("> (Planner) How evenly can the food items be redistributed among the elves?")
+
+	start := time.Now()
+
+	plan, err := elfs.PlanRedistribution(elvesCount, mode)
+	if err != nil {
+		log.Fatalf("Error planning redistribution: %v", err)
+		return
+	}
+
+	for id, load := range plan.Loads {
+		log.Printf("Elf (%d): %d calories, %d items", id, load, len(plan.Items[id]))
+	}
+
+	for _, m := range plan.Moves {
+		log.Printf("Move item %d (%d calories) from elf %d to elf %d", m.Item.Index, m.Item.Calories, m.Item.ElfId, m.ToElf)
+	}
+
+	log.Printf("Heaviest load before: %d, after: %d (lower bound %d, optimal: %v)", elfs.FindElfMostCalories().GetTotalCalories(), plan.MaxLoad, plan.LowerBound, plan.IsOptimal())
+	log.Printf("Items moved: %d", len(plan.Moves))
+	log.Printf("End Planner (%s mode) - %s", plan.Mode, time.Since(start))
+}
+
+func parseFile(filePath string, format string) (Elfs, error) {
+	reader, ok := ElfsReaders[format]
+	if !ok {
+		return Elfs{}, fmt.Errorf("unknown input format: %v", format)
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return Elfs{}, err
+	}
+	defer file.Close()
+
+	return reader(file)
+}
+
+func writeFile(filePath string, elfs Elfs) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return WritePuzzleFormat(file, elfs)
+}
+
+// Elves are kept in input order, the Id of each elf is its position in the
+// file and StartLine/EndLine are the (1-based) lines holding its items
+func linesToElfs(lines []string) (Elfs, error) {
+	var result []Elf
+	current := Elf{Id: 0, StartLine: 1}
+
+	for i, line := range lines {
+		lineNumber := i + 1
 		line = strings.TrimSpace(line)
 		if line == "" {
-			elfNumber = elfNumber + 1
-			result[elfNumber] = Elf{Id: elfNumber}
+			result = append(result, current)
+			current = Elf{Id: len(result), StartLine: lineNumber + 1}
 			continue
 		}
 		calorie, err := strconv.Atoi(line)
 		if err != nil {
-			log.Fatalf("Error while parsing line to int: %v", err)
-			return nil, err
+			return Elfs{}, fmt.Errorf("line %d: %v", lineNumber, err)
 		}
 
-		result[elfNumber] = result[elfNumber].AddCalorie(calorie)
+		current = current.AddCalorie(calorie)
+		current.EndLine = lineNumber
 	}
 
-	return result, nil
+	// a file ending with a blank line has no elf after it
+	if current.EndLine > 0 || len(result) == 0 {
+		result = append(result, current)
+	}
+
+	return Elfs{List: result}, nil
 }
 
 func getMostCalories(filePath string) (int, error) {
@@ -144,10 +261,15 @@ func getFileLines(filePath string) ([]string, error) {
 }
 
 type Elfs struct {
-	List map[int]Elf
+	List []Elf // in input order, List[i].Id == i
 }
 
+// On ties the elf that appears first in the input wins
 func (this Elfs) FindElfMostCalories() Elf {
+	if len(this.List) == 0 {
+		return Elf{}
+	}
+
 	most := this.List[0]
 	for i := 1; i < len(this.List); i++ {
 		if most.GetTotalCalories() < this.List[i].GetTotalCalories() {
@@ -158,25 +280,30 @@ func (this Elfs) FindElfMostCalories() Elf {
 	return most
 }
 
+// On ties the elves keep their input order
 func (this Elfs) FindElvesThatCarryMostCalories(elvesCount int) []Elf {
 	elves := make([]Elf, len(this.List))
+	copy(elves, this.List)
 
-	for i, elf := range this.List {
-		elves[i] = elf
-	}
-
-	sort.Slice(elves, func(i, j int) bool {
-		return elves[i].GetTotalCalories() >= elves[j].GetTotalCalories()
+	sort.SliceStable(elves, func(i, j int) bool {
+		return elves[i].GetTotalCalories() > elves[j].GetTotalCalories()
 	})
 
+	if elvesCount > len(elves) {
+		elvesCount = len(elves)
+	}
+
 	topElves := elves[0:elvesCount]
 
 	return topElves
 }
 
 type Elf struct {
-	Id       int
-	Calories []int
+	Id        int
+	Name      string // only set by the csv and json formats
+	StartLine int    // first line of the elf's items (1-based), 0 for the csv and json formats
+	EndLine   int    // last line of the elf's items, 0 if the elf carries nothing
+	Calories  []int
 }
 
 func (this Elf) GetTotalCalories() int {
@@ -187,6 +314,13 @@ func (this Elf) GetTotalCalories() int {
 	return sum
 }
 
+func (this Elf) DisplayName() string {
+	if this.Name != "" {
+		return this.Name
+	}
+	return strconv.Itoa(this.Id)
+}
+
 func (this Elf) AddCalorie(calorie int) Elf {
 	this.Calories = append(this.Calories, calorie)
 	return this
@@ -0,0 +1,103 @@
+package main
+
+import "fmt"
+
+type CalorieSelection struct {
+	Target   int
+	Calories int // sum of the selected items, never above Target
+	Items    []FoodItem
+}
+
+func (s CalorieSelection) IsExact() bool {
+	return s.Calories == s.Target
+}
+
+// Finds the items of the elf whose calories add up to target, or get as
+// close as possible to it without going over
+func (this Elf) SelectCaloriesForTarget(target int) (CalorieSelection, error) {
+	items := make([]FoodItem, len(this.Calories))
+	for i, c := range this.Calories {
+		items[i] = FoodItem{ElfId: this.Id, Index: i, Calories: c}
+	}
+	return selectItemsForTarget(items, target)
+}
+
+// Same as Elf.SelectCaloriesForTarget but over the items of every elf
+func (this Elfs) SelectCaloriesForTarget(target int) (CalorieSelection, error) {
+	var items []FoodItem
+	for _, elf := range this.List {
+		for i, c := range elf.Calories {
+			items = append(items, FoodItem{ElfId: elf.Id, Index: i, Calories: c})
+		}
+	}
+	return selectItemsForTarget(items, target)
+}
+
+// Subset sum by dynamic programming: reachedBy[s] is the first item that made
+// the sum s reachable (or -1), so following it back rebuilds the selection
+func selectItemsForTarget(items []FoodItem, target int) (CalorieSelection, error) {
+	if target < 0 {
+		return CalorieSelection{}, fmt.Errorf("invalid target: %d", target)
+	}
+
+	// No sum above the calories of all the items is reachable, when that is
+	// not above target every item is selected and the table below is only
+	// ever as large as the inventory
+	total := 0
+	for _, item := range items {
+		if item.Calories > 0 {
+			total += item.Calories
+		}
+	}
+	if total <= target {
+		result := CalorieSelection{Target: target, Calories: total}
+		for _, item := range items {
+			if item.Calories > 0 {
+				result.Items = append(result.Items, item)
+			}
+		}
+		return result, nil
+	}
+
+	reachedBy := make([]int, target+1)
+	for s := range reachedBy {
+		reachedBy[s] = -1
+	}
+
+	best := 0
+
+	for i, item := range items {
+		c := item.Calories
+		if c <= 0 || c > target {
+			continue
+		}
+		for s := target; s >= c; s-- {
+			if reachedBy[s] != -1 || (s != c && reachedBy[s-c] == -1) {
+				continue
+			}
+			reachedBy[s] = i
+			if s > best {
+				best = s
+			}
+		}
+		if best == target {
+			break
+		}
+	}
+
+	result := CalorieSelection{
+		Target:   target,
+		Calories: best,
+	}
+
+	for s := best; s > 0; s -= items[reachedBy[s]].Calories {
+		result.Items = append(result.Items, items[reachedBy[s]])
+	}
+
+	// back in the order the items were given
+	for i, j := 0, len(result.Items)-1; i < j; i, j = i+1, j-1 {
+		result.Items[i], result.Items[j] = result.Items[j], result.Items[i]
+	}
+
+	return result, nil
+}
@@ -0,0 +1,385 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	RedistributionModeAuto   = "auto"
+	RedistributionModeExact  = "exact"
+	RedistributionModeGreedy = "greedy"
+
+	// Above this number of items the exact solver may take too long and the
+	// auto mode falls back to the greedy one
+	RedistributionExactMaxItems = 24
+)
+
+type FoodItem struct {
+	ElfId    int // elf carrying the item in the input
+	Index    int // position of the item in that elf's Calories
+	Calories int
+}
+
+type ItemMove struct {
+	Item  FoodItem
+	ToElf int
+}
+
+type RedistributionPlan struct {
+	Mode       string
+	Loads      []int        // total calories per elf after the redistribution
+	Items      [][]FoodItem // items carried by each elf after the redistribution
+	Moves      []ItemMove   // items that changed elf
+	MaxLoad    int
+	LowerBound int // no redistribution can have a max load below this value
+}
+
+func (p RedistributionPlan) IsOptimal() bool {
+	return p.MaxLoad == p.LowerBound || p.Mode == RedistributionModeExact
+}
+
+// Redistributes the food items of all elves among elvesCount elves so that the
+// heaviest load is as small as possible (multiway number partitioning).
+// An elvesCount of 0 keeps the current number of elves
+func (this Elfs) PlanRedistribution(elvesCount int, mode string) (RedistributionPlan, error) {
+	if elvesCount == 0 {
+		elvesCount = len(this.List)
+	}
+	if elvesCount < 1 {
+		return RedistributionPlan{}, fmt.Errorf("invalid number of elves: %d", elvesCount)
+	}
+
+	var items []FoodItem
+	for _, elf := range this.List {
+		for i, c := range elf.Calories {
+			items = append(items, FoodItem{ElfId: elf.Id, Index: i, Calories: c})
+		}
+	}
+
+	// Heaviest first, ties by input order so the plan is deterministic
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Calories > items[j].Calories
+	})
+
+	if mode == RedistributionModeAuto {
+		mode = RedistributionModeGreedy
+		if len(items) <= RedistributionExactMaxItems {
+			mode = RedistributionModeExact
+		}
+	}
+
+	var bins []int
+	switch mode {
+	case RedistributionModeGreedy:
+		// Improving the current loads usually moves far fewer items than
+		// starting over from LPT, so it wins unless LPT is strictly better
+		bins = improvePartitionLocally(items, partitionFromOwners(items, elvesCount), elvesCount)
+		lpt := improvePartitionLocally(items, partitionLPT(items, elvesCount), elvesCount)
+		if partitionPeak(items, lpt, elvesCount) < partitionPeak(items, bins, elvesCount) {
+			bins = lpt
+		}
+	case RedistributionModeExact:
+		bins = partitionExact(items, elvesCount)
+	default:
+		return RedistributionPlan{}, fmt.Errorf("unknown redistribution mode: %v", mode)
+	}
+
+	bins = relabelBinsToOwners(items, bins, elvesCount)
+
+	plan := RedistributionPlan{
+		Mode:       mode,
+		Loads:      make([]int, elvesCount),
+		Items:      make([][]FoodItem, elvesCount),
+		LowerBound: partitionLowerBound(items, elvesCount),
+	}
+
+	for i, item := range items {
+		b := bins[i]
+		plan.Loads[b] += item.Calories
+		plan.Items[b] = append(plan.Items[b], item)
+		if b != item.ElfId {
+			plan.Moves = append(plan.Moves, ItemMove{Item: item, ToElf: b})
+		}
+	}
+
+	sort.SliceStable(plan.Moves, func(i, j int) bool {
+		if plan.Moves[i].Item.ElfId != plan.Moves[j].Item.ElfId {
+			return plan.Moves[i].Item.ElfId < plan.Moves[j].Item.ElfId
+		}
+		return plan.Moves[i].Item.Index < plan.Moves[j].Item.Index
+	})
+
+	for _, l := range plan.Loads {
+		if l > plan.MaxLoad {
+			plan.MaxLoad = l
+		}
+	}
+
+	return plan, nil
+}
+
+func partitionLowerBound(items []FoodItem, binsCount int) int {
+	sum := 0
+	largest := 0
+	for _, it := range items {
+		sum += it.Calories
+		if it.Calories > largest {
+			largest = it.Calories
+		}
+	}
+	average := (sum + binsCount - 1) / binsCount
+	if largest > average {
+		return largest
+	}
+	return average
+}
+
+// Longest Processing Time first: items (sorted heaviest first) always go to
+// the least loaded bin
+func partitionLPT(items []FoodItem, binsCount int) []int {
+	bins := make([]int, len(items))
+	loads := make([]int, binsCount)
+
+	for i, item := range items {
+		lightest := 0
+		for b := 1; b < binsCount; b++ {
+			if loads[b] < loads[lightest] {
+				lightest = b
+			}
+		}
+		bins[i] = lightest
+		loads[lightest] += item.Calories
+	}
+
+	return bins
+}
+
+// Items stay with their elf, items of elves beyond binsCount go to the least
+// loaded bin
+func partitionFromOwners(items []FoodItem, binsCount int) []int {
+	bins := make([]int, len(items))
+	loads := make([]int, binsCount)
+
+	for i, item := range items {
+		if item.ElfId < binsCount {
+			bins[i] = item.ElfId
+			loads[item.ElfId] += item.Calories
+		}
+	}
+
+	for i, item := range items {
+		if item.ElfId < binsCount {
+			continue
+		}
+		lightest := 0
+		for b := 1; b < binsCount; b++ {
+			if loads[b] < loads[lightest] {
+				lightest = b
+			}
+		}
+		bins[i] = lightest
+		loads[lightest] += item.Calories
+	}
+
+	return bins
+}
+
+func partitionPeak(items []FoodItem, bins []int, binsCount int) int {
+	loads := make([]int, binsCount)
+	peak := 0
+	for i, item := range items {
+		loads[bins[i]] += item.Calories
+		peak = maxInt(peak, loads[bins[i]])
+	}
+	return peak
+}
+
+// Moves or swaps items out of the heaviest bin while that makes the pair of
+// bins involved lighter than the heaviest one was
+func improvePartitionLocally(items []FoodItem, bins []int, binsCount int) []int {
+	loads := make([]int, binsCount)
+	for i, item := range items {
+		loads[bins[i]] += item.Calories
+	}
+
+	for {
+		heaviest := 0
+		for b := 1; b < binsCount; b++ {
+			if loads[b] > loads[heaviest] {
+				heaviest = b
+			}
+		}
+
+		bestPeak := loads[heaviest]
+		bestItem, bestOther := -1, -1
+
+		for i, item := range items {
+			if bins[i] != heaviest {
+				continue
+			}
+
+			// move
+			for b := 0; b < binsCount; b++ {
+				if b == heaviest {
+					continue
+				}
+				peak := maxInt(loads[heaviest]-item.Calories, loads[b]+item.Calories)
+				if peak < bestPeak {
+					bestPeak, bestItem, bestOther = peak, i, -1-b
+				}
+			}
+
+			// swap
+			for j, other := range items {
+				if bins[j] == heaviest || other.Calories >= item.Calories {
+					continue
+				}
+				diff := item.Calories - other.Calories
+				peak := maxInt(loads[heaviest]-diff, loads[bins[j]]+diff)
+				if peak < bestPeak {
+					bestPeak, bestItem, bestOther = peak, i, j
+				}
+			}
+		}
+
+		if bestItem == -1 {
+			return bins
+		}
+
+		if bestOther < 0 {
+			to := -1 - bestOther
+			loads[heaviest] -= items[bestItem].Calories
+			loads[to] += items[bestItem].Calories
+			bins[bestItem] = to
+		} else {
+			to := bins[bestOther]
+			diff := items[bestItem].Calories - items[bestOther].Calories
+			loads[heaviest] -= diff
+			loads[to] += diff
+			bins[bestItem], bins[bestOther] = to, heaviest
+		}
+	}
+}
+
+// Branch and bound over the items (sorted heaviest first), starting from the
+// LPT solution and stopping as soon as the lower bound is reached
+func partitionExact(items []FoodItem, binsCount int) []int {
+	best := partitionLPT(items, binsCount)
+	bestPeak := partitionPeak(items, best, binsCount)
+
+	lowerBound := partitionLowerBound(items, binsCount)
+	loads := make([]int, binsCount)
+	current := make([]int, len(items))
+
+	var search func(i int, peak int) bool
+	search = func(i int, peak int) bool {
+		if i == len(items) {
+			bestPeak = peak
+			copy(best, current)
+			return bestPeak == lowerBound
+		}
+
+		for b := 0; b < binsCount; b++ {
+			newLoad := loads[b] + items[i].Calories
+			if newLoad >= bestPeak {
+				continue
+			}
+
+			// bins with the same load are interchangeable, try only the first
+			duplicated := false
+			for o := 0; o < b; o++ {
+				if loads[o] == loads[b] {
+					duplicated = true
+					break
+				}
+			}
+			if duplicated {
+				continue
+			}
+
+			loads[b] = newLoad
+			current[i] = b
+			done := search(i+1, maxInt(peak, newLoad))
+			loads[b] -= items[i].Calories
+			if done {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if bestPeak > lowerBound {
+		search(0, 0)
+	}
+
+	return best
+}
+
+// Bins are interchangeable, so each bin is given to the elf that already
+// carries most of its calories, reducing the number of items that move
+func relabelBinsToOwners(items []FoodItem, bins []int, binsCount int) []int {
+	type overlap struct {
+		bin, elf, calories int
+	}
+
+	byPair := make(map[[2]int]int)
+	for i, item := range items {
+		if item.ElfId < binsCount {
+			byPair[[2]int{bins[i], item.ElfId}] += item.Calories
+		}
+	}
+
+	overlaps := make([]overlap, 0, len(byPair))
+	for k, v := range byPair {
+		overlaps = append(overlaps, overlap{bin: k[0], elf: k[1], calories: v})
+	}
+	sort.Slice(overlaps, func(i, j int) bool {
+		if overlaps[i].calories != overlaps[j].calories {
+			return overlaps[i].calories > overlaps[j].calories
+		}
+		if overlaps[i].bin != overlaps[j].bin {
+			return overlaps[i].bin < overlaps[j].bin
+		}
+		return overlaps[i].elf < overlaps[j].elf
+	})
+
+	binToElf := make([]int, binsCount)
+	for b := range binToElf {
+		binToElf[b] = -1
+	}
+	elfTaken := make([]bool, binsCount)
+
+	for _, o := range overlaps {
+		if binToElf[o.bin] == -1 && !elfTaken[o.elf] {
+			binToElf[o.bin] = o.elf
+			elfTaken[o.elf] = true
+		}
+	}
+
+	nextElf := 0
+	for b := range binToElf {
+		if binToElf[b] != -1 {
+			continue
+		}
+		for elfTaken[nextElf] {
+			nextElf++
+		}
+		binToElf[b] = nextElf
+		elfTaken[nextElf] = true
+	}
+
+	result := make([]int, len(bins))
+	for i, b := range bins {
+		result[i] = binToElf[b]
+	}
+	return result
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
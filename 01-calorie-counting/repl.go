@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const ReplHelp = `Commands:
+  add <elf> <calories>      add an item to the elf
+  remove <elf> <calories>   remove an item with those calories from the elf
+  addelf                    add an elf carrying nothing
+  removeelf <elf>           remove the elf and its items
+  top <k>                   the k elves carrying the most calories
+  rank <elf>                position of the elf in the ranking
+  show <elf>                items carried by the elf
+  help                      this message
+  quit                      leave`
+
+// Reads commands from in and applies them to the inventory until quit or
+// the end of the input
+func RunInventoryRepl(inv *Inventory, in io.Reader, out io.Writer) {
+	scanner := bufio.NewScanner(in)
+
+	fmt.Fprint(out, "> ")
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+
+		if len(fields) > 0 {
+			if fields[0] == "quit" || fields[0] == "exit" {
+				return
+			}
+			if err := runReplCommand(inv, fields, out); err != nil {
+				fmt.Fprintf(out, "error: %v\n", err)
+			}
+		}
+
+		fmt.Fprint(out, "> ")
+	}
+	fmt.Fprintln(out)
+}
+
+func runReplCommand(inv *Inventory, fields []string, out io.Writer) error {
+	args, err := replArgsToInts(fields[1:])
+	if err != nil {
+		return err
+	}
+
+	expectArgs := func(count int) error {
+		if len(args) != count {
+			return fmt.Errorf("%v expects %d arguments, got %d", fields[0], count, len(args))
+		}
+		return nil
+	}
+
+	switch fields[0] {
+	case "add":
+		if err := expectArgs(2); err != nil {
+			return err
+		}
+		if err := inv.AddItem(args[0], args[1]); err != nil {
+			return err
+		}
+		return printReplElf(inv, args[0], out)
+	case "remove":
+		if err := expectArgs(2); err != nil {
+			return err
+		}
+		if err := inv.RemoveItem(args[0], args[1]); err != nil {
+			return err
+		}
+		return printReplElf(inv, args[0], out)
+	case "addelf":
+		if err := expectArgs(0); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Added elf (%d)\n", inv.AddElf())
+	case "removeelf":
+		if err := expectArgs(1); err != nil {
+			return err
+		}
+		if err := inv.RemoveElf(args[0]); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Removed elf (%d), %d elves left\n", args[0], inv.Len())
+	case "top":
+		if err := expectArgs(1); err != nil {
+			return err
+		}
+		if args[0] < 1 {
+			return fmt.Errorf("top expects a positive number of elves, got %d", args[0])
+		}
+		total := 0
+		for i, elf := range inv.Top(args[0]) {
+			total += elf.GetTotalCalories()
+			fmt.Fprintf(out, "%d. Elf (%d): %d\n", i+1, elf.Id, elf.GetTotalCalories())
+		}
+		fmt.Fprintf(out, "Total: %d\n", total)
+	case "rank":
+		if err := expectArgs(1); err != nil {
+			return err
+		}
+		return printReplElf(inv, args[0], out)
+	case "show":
+		if err := expectArgs(1); err != nil {
+			return err
+		}
+		elf, ok := inv.Elf(args[0])
+		if !ok {
+			return fmt.Errorf("there is no elf %d", args[0])
+		}
+		fmt.Fprintf(out, "Elf (%d): %v\n", elf.Id, elf.Calories)
+		return printReplElf(inv, args[0], out)
+	case "help":
+		fmt.Fprintln(out, ReplHelp)
+	default:
+		return fmt.Errorf("unknown command %q, try help", fields[0])
+	}
+
+	return nil
+}
+
+func printReplElf(inv *Inventory, id int, out io.Writer) error {
+	rank, err := inv.Rank(id)
+	if err != nil {
+		return err
+	}
+	elf, _ := inv.Elf(id)
+	fmt.Fprintf(out, "Elf (%d): %d calories, rank %d of %d\n", id, elf.GetTotalCalories(), rank, inv.Len())
+	return nil
+}
+
+func replArgsToInts(args []string) ([]int, error) {
+	result := make([]int, len(args))
+	for i, a := range args {
+		v, err := strconv.Atoi(a)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", a)
+		}
+		result[i] = v
+	}
+	return result, nil
+}
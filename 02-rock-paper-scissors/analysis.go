@@ -0,0 +1,85 @@
+package main
+
+import "fmt"
+
+type RoundAnalysis struct {
+	OpponentMove  Move
+	BestMove      Move // the first move with the best score on ties
+	BestScore     int
+	WorstScore    int
+	ExpectedScore float64 // of a player picking a move uniformly at random
+}
+
+// How the guide compares with the best, the worst and a random player
+// against the same opponent moves
+type GuideAnalysis struct {
+	Rounds        []RoundAnalysis
+	MaxScore      uint
+	MinScore      uint
+	ExpectedScore float64
+	GuideScore    uint
+}
+
+// Where the guide score sits between the minimum (0) and the maximum (1)
+func (a GuideAnalysis) Efficiency() float64 {
+	if a.MaxScore == a.MinScore {
+		return 1
+	}
+	return float64(a.GuideScore-a.MinScore) / float64(a.MaxScore-a.MinScore)
+}
+
+func analyzeRound(r Rules, om Move) RoundAnalysis {
+	result := RoundAnalysis{OpponentMove: om}
+	sum := 0
+
+	for m := Move(0); int(m) < r.MovesCount(); m++ {
+		score := r.computeScore(m, om)[0]
+		sum += score
+		if m == 0 || score > result.BestScore {
+			result.BestMove = m
+			result.BestScore = score
+		}
+		if m == 0 || score < result.WorstScore {
+			result.WorstScore = score
+		}
+	}
+
+	result.ExpectedScore = float64(sum) / float64(r.MovesCount())
+
+	return result
+}
+
+func (g Guide) Analyze(r Rules, in Interpretation) (GuideAnalysis, error) {
+	games, err := g.Play(r, in)
+	if err != nil {
+		return GuideAnalysis{}, err
+	}
+
+	result := GuideAnalysis{
+		Rounds:     make([]RoundAnalysis, len(g)),
+		GuideScore: games.ComputePlayerScore(),
+	}
+
+	// the analysis only depends on the opponent move
+	byOpponentMove := make(map[Move]RoundAnalysis)
+
+	for i, round := range g {
+		ra, ok := byOpponentMove[round.OpponentMove]
+		if !ok {
+			ra = analyzeRound(r, round.OpponentMove)
+			byOpponentMove[round.OpponentMove] = ra
+		}
+
+		result.Rounds[i] = ra
+		result.MaxScore += uint(ra.BestScore)
+		result.MinScore += uint(ra.WorstScore)
+		result.ExpectedScore += ra.ExpectedScore
+	}
+
+	return result, nil
+}
+
+func (a GuideAnalysis) String() string {
+	return fmt.Sprintf("max %d, min %d, random %.1f, guide %d (%.1f%% of the way from min to max)",
+		a.MaxScore, a.MinScore, a.ExpectedScore, a.GuideScore, a.Efficiency()*100)
+}
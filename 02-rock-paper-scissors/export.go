@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatJSON = "json"
+)
+
+type RoundBreakdown struct {
+	Round                 int    `json:"round"`
+	OpponentMove          string `json:"opponentMove"`
+	PlayerMove            string `json:"playerMove"`
+	Outcome               string `json:"outcome"` // for the player
+	PlayerShapePoints     int    `json:"playerShapePoints"`
+	PlayerOutcomePoints   int    `json:"playerOutcomePoints"`
+	OpponentShapePoints   int    `json:"opponentShapePoints"`
+	OpponentOutcomePoints int    `json:"opponentOutcomePoints"`
+	PlayerTotal           int    `json:"playerTotal"`   // running total
+	OpponentTotal         int    `json:"opponentTotal"` // running total
+}
+
+type ScoreBreakdown struct {
+	Rounds        []RoundBreakdown `json:"rounds"`
+	PlayerScore   uint             `json:"playerScore"`
+	OpponentScore uint             `json:"opponentScore"`
+	Wins          int              `json:"wins"`
+	Draws         int              `json:"draws"`
+	Losses        int              `json:"losses"`
+}
+
+func (gs Games) ComputeOpponentScore() uint {
+	sum := uint(0)
+
+	for _, g := range gs {
+		sum += uint(g.Score[1])
+	}
+
+	return sum
+}
+
+func (gs Games) Breakdown(r Rules) ScoreBreakdown {
+	result := ScoreBreakdown{
+		Rounds: make([]RoundBreakdown, len(gs)),
+	}
+
+	playerTotal, opponentTotal := 0, 0
+
+	for i, g := range gs {
+		outcome := r.Outcome(g.PlayerMove, g.OpponentMove)
+		playerTotal += g.Score[0]
+		opponentTotal += g.Score[1]
+
+		result.Rounds[i] = RoundBreakdown{
+			Round:                 i + 1,
+			OpponentMove:          r.Names[g.OpponentMove],
+			PlayerMove:            r.Names[g.PlayerMove],
+			Outcome:               outcome.String(),
+			PlayerShapePoints:     r.ShapeScores[g.PlayerMove],
+			PlayerOutcomePoints:   g.Score[0] - r.ShapeScores[g.PlayerMove],
+			OpponentShapePoints:   r.ShapeScores[g.OpponentMove],
+			OpponentOutcomePoints: g.Score[1] - r.ShapeScores[g.OpponentMove],
+			PlayerTotal:           playerTotal,
+			OpponentTotal:         opponentTotal,
+		}
+
+		switch outcome {
+		case Win:
+			result.Wins++
+		case Draw:
+			result.Draws++
+		case Loss:
+			result.Losses++
+		}
+	}
+
+	result.PlayerScore = gs.ComputePlayerScore()
+	result.OpponentScore = gs.ComputeOpponentScore()
+
+	return result
+}
+
+// Format given by the file extension, csv when unknown
+func exportFormatFromPath(filePath string) string {
+	if strings.ToLower(filepath.Ext(filePath)) == ".json" {
+		return ExportFormatJSON
+	}
+	return ExportFormatCSV
+}
+
+func (b ScoreBreakdown) Write(w io.Writer, format string) error {
+	switch format {
+	case ExportFormatCSV:
+		return b.WriteCSV(w)
+	case ExportFormatJSON:
+		return b.WriteJSON(w)
+	}
+	return fmt.Errorf("unknown export format: %v", format)
+}
+
+// One row per round, the totals are the running totals of the last row
+func (b ScoreBreakdown) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	err := writer.Write([]string{
+		"round", "opponent_move", "player_move", "outcome",
+		"player_shape_points", "player_outcome_points",
+		"opponent_shape_points", "opponent_outcome_points",
+		"player_total", "opponent_total",
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, rb := range b.Rounds {
+		err := writer.Write([]string{
+			strconv.Itoa(rb.Round), rb.OpponentMove, rb.PlayerMove, rb.Outcome,
+			strconv.Itoa(rb.PlayerShapePoints), strconv.Itoa(rb.PlayerOutcomePoints),
+			strconv.Itoa(rb.OpponentShapePoints), strconv.Itoa(rb.OpponentOutcomePoints),
+			strconv.Itoa(rb.PlayerTotal), strconv.Itoa(rb.OpponentTotal),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func (b ScoreBreakdown) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(b)
+}
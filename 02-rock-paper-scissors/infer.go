@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A possible key of the strategy guide second column and the score the guide
+// gets with it
+type MappingCandidate struct {
+	Interpretation Interpretation
+	Rules          Rules // the rules with the candidate letters
+	Score          uint
+}
+
+func (c MappingCandidate) String() string {
+	var parts []string
+	switch c.Interpretation.(type) {
+	case OutcomeInterpretation:
+		for l, o := range c.Rules.OutcomeLetters {
+			parts = append(parts, fmt.Sprintf("%v=%v", l, o))
+		}
+	default:
+		for l, m := range c.Rules.PlayerLetters {
+			parts = append(parts, fmt.Sprintf("%v=%v", l, c.Rules.Names[m]))
+		}
+	}
+	sort.Strings(parts)
+	return fmt.Sprintf("%v %v", c.Interpretation.Name(), strings.Join(parts, ", "))
+}
+
+// Letters used in the second column, sorted
+func (g Guide) ColumnLetters() []string {
+	seen := make(map[string]bool)
+	var letters []string
+	for _, round := range g {
+		if !seen[round.Column] {
+			seen[round.Column] = true
+			letters = append(letters, round.Column)
+		}
+	}
+	sort.Strings(letters)
+	return letters
+}
+
+// Scores the guide with every one-to-one mapping of the second column letters
+// to moves and to outcomes (N! and 3! of them for N letters)
+func (g Guide) ScoreAllMappings(r Rules) []MappingCandidate {
+	letters := g.ColumnLetters()
+	var result []MappingCandidate
+
+	forEachInjection(len(letters), r.MovesCount(), func(values []int) {
+		candidate := r
+		candidate.PlayerLetters = make(map[string]Move, len(letters))
+		for i, l := range letters {
+			candidate.PlayerLetters[l] = Move(values[i])
+		}
+		result = g.appendCandidate(result, candidate, MoveInterpretation{})
+	})
+
+	forEachInjection(len(letters), 3, func(values []int) {
+		candidate := r
+		candidate.OutcomeLetters = make(map[string]Outcome, len(letters))
+		for i, l := range letters {
+			candidate.OutcomeLetters[l] = Outcome(values[i])
+		}
+		result = g.appendCandidate(result, candidate, OutcomeInterpretation{})
+	})
+
+	return result
+}
+
+// The mappings giving exactly the target score and the number of mappings tried
+func (g Guide) InferMappings(r Rules, target uint) ([]MappingCandidate, int) {
+	candidates := g.ScoreAllMappings(r)
+
+	var result []MappingCandidate
+	for _, c := range candidates {
+		if c.Score == target {
+			result = append(result, c)
+		}
+	}
+
+	return result, len(candidates)
+}
+
+func (g Guide) appendCandidate(result []MappingCandidate, r Rules, in Interpretation) []MappingCandidate {
+	games, err := g.Play(r, in)
+	if err != nil {
+		// with non cyclic rules some outcome may be impossible against a move,
+		// such mapping cannot be the right one
+		return result
+	}
+	return append(result, MappingCandidate{
+		Interpretation: in,
+		Rules:          r,
+		Score:          games.ComputePlayerScore(),
+	})
+}
+
+// Calls f with every way of giving k positions distinct values in [0, n)
+func forEachInjection(k int, n int, f func(values []int)) {
+	if k > n {
+		return
+	}
+
+	values := make([]int, k)
+	used := make([]bool, n)
+
+	var walk func(i int)
+	walk = func(i int) {
+		if i == k {
+			f(values)
+			return
+		}
+		for v := 0; v < n; v++ {
+			if used[v] {
+				continue
+			}
+			used[v] = true
+			values[i] = v
+			walk(i + 1)
+			used[v] = false
+		}
+	}
+
+	walk(0)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A meaning of the second column of the strategy guide
+type Interpretation interface {
+	Name() string
+	Description() string
+	PlayerMove(r Rules, round Round) (Move, error)
+}
+
+var Interpretations = map[string]Interpretation{
+	"move":    MoveInterpretation{},
+	"outcome": OutcomeInterpretation{},
+	"offset":  OffsetInterpretation{},
+}
+
+func InterpretationNames() []string {
+	names := make([]string, 0, len(Interpretations))
+	for name := range Interpretations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func interpretationByName(name string) (Interpretation, error) {
+	in, ok := Interpretations[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown interpretation %q, expected one of %v", name, strings.Join(InterpretationNames(), ", "))
+	}
+	return in, nil
+}
+
+// The column is the move to play (1st puzzle)
+type MoveInterpretation struct{}
+
+func (MoveInterpretation) Name() string { return "move" }
+
+func (MoveInterpretation) Description() string { return "column is my move" }
+
+func (MoveInterpretation) PlayerMove(r Rules, round Round) (Move, error) {
+	pm, ok := r.PlayerLetters[round.Column]
+	if !ok {
+		return 0, fmt.Errorf("unknown player move %q", round.Column)
+	}
+	return pm, nil
+}
+
+// The column is how the round must end (2nd puzzle)
+type OutcomeInterpretation struct{}
+
+func (OutcomeInterpretation) Name() string { return "outcome" }
+
+func (OutcomeInterpretation) Description() string { return "column is desired outcome" }
+
+func (OutcomeInterpretation) PlayerMove(r Rules, round Round) (Move, error) {
+	outcome, ok := r.OutcomeLetters[round.Column]
+	if !ok {
+		return 0, fmt.Errorf("unknown outcome %q", round.Column)
+	}
+	pm, ok := r.MoveForOutcome(round.OpponentMove, outcome)
+	if !ok {
+		return 0, fmt.Errorf("no move gives a %v against %v", outcome, r.Names[round.OpponentMove])
+	}
+	return pm, nil
+}
+
+// The column is how many moves after the opponent one to play, the offset of
+// each letter being the index of the move it stands for (X - 0, Y - 1, ...)
+type OffsetInterpretation struct{}
+
+func (OffsetInterpretation) Name() string { return "offset" }
+
+func (OffsetInterpretation) Description() string { return "column is offset from opponent" }
+
+func (OffsetInterpretation) PlayerMove(r Rules, round Round) (Move, error) {
+	offset, ok := r.PlayerLetters[round.Column]
+	if !ok {
+		return 0, fmt.Errorf("unknown offset %q", round.Column)
+	}
+	return Move((int(round.OpponentMove) + int(offset)) % r.MovesCount()), nil
+}
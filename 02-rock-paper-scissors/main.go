@@ -6,99 +6,234 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"math/rand"
 	"os"
 	"strings"
 )
 
 func main() {
 	var inputFilePath = flag.String("inputFilePath", "./inputf.txt", "Input File")
+	var rulesFilePath = flag.String("rulesFilePath", "", "Game rules json file (default - rock, paper, scissors)")
+	var interpretation = flag.String("interpretation", "", "Also score the guide reading its second column as: "+strings.Join(InterpretationNames(), ", ")+" (default for the other modes - move)")
+	var targetScore = flag.Uint("targetScore", 0, "Find the second column mappings that give the guide this score (0 - disabled)")
+	var analyze = flag.Bool("analyze", false, "Compare the guide with the best, worst and random players and log the best move of every round")
+	var tournament = flag.Bool("tournament", false, "Play a round-robin tournament between the strategy bots and the guide")
+	var tournamentRounds = flag.Int("tournamentRounds", 1000, "Rounds of every tournament match")
+	var seed = flag.Int64("seed", 1, "Seed of the random choices")
+	var predict = flag.Bool("predict", false, "Score a Markov predictor countering the guide opponent moves")
+	var predictOrder = flag.Int("predictOrder", 2, "Number of previous opponent moves the predictor looks at")
+	var exportFilePath = flag.String("exportFilePath", "", "Write the per round score breakdown of both players to this csv or json file")
 	flag.Parse()
 
 	log.Printf("inputFilePath %v\n", *inputFilePath)
 
-	log.Printf("> (1st Puzzle) What would your total score be if everything goes exactly according to your strategy guide?")
+	rules := DefaultRules()
+
+	if *rulesFilePath != "" {
+		var err error
+		rules, err = LoadRules(*rulesFilePath)
+		if err != nil {
+			log.Fatalf("Error loading rules %v: %v", *rulesFilePath, err)
+			return
+		}
+	}
 
-	games, err := parseFile(*inputFilePath)
+	guide, err := parseFile(*inputFilePath, rules)
 
 	if err != nil {
 		log.Fatalf("Error Parsing file %v: %v", *inputFilePath, err)
 		return
 	}
 
-	finalPlayerScore := games.ComputePlayerScore()
+	// Interpretation of the analysis, tournament, prediction and export, the
+	// 1st puzzle one by default
+	var in Interpretation = MoveInterpretation{}
+
+	if *interpretation != "" {
+		in, err = interpretationByName(*interpretation)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+			return
+		}
+	}
+
+	log.Printf("> (1st Puzzle) What would your total score be if everything goes exactly according to your strategy guide?")
+
+	// Custom rules may not read the second column as a move or as an outcome,
+	// the other modes still run when a puzzle cannot be played
+	games, err := guide.Play(rules, MoveInterpretation{})
 
-	log.Printf("Final Player Score is: %d", finalPlayerScore)
+	if err != nil {
+		log.Printf("Error playing guide: %v", err)
+	} else {
+		log.Printf("Final Player Score is: %d", games.ComputePlayerScore())
+	}
 
 	log.Printf("> (2nd Puzzle) Following the Elf's instructions for the second column, what would your total score be if everything goes exactly according to your strategy guide?")
 
-	games, err = parseFile2(*inputFilePath)
+	games, err = guide.Play(rules, OutcomeInterpretation{})
 
 	if err != nil {
-		log.Fatalf("Error Parsing file %v: %v", *inputFilePath, err)
-		return
+		log.Printf("Error playing guide: %v", err)
+	} else {
+		log.Printf("Final Player Score is: %d", games.ComputePlayerScore())
 	}
 
-	finalPlayerScore = games.ComputePlayerScore()
+	if *interpretation != "" {
+		log.Printf("> (Interpretation) What would your total score be if the %v?", in.Description())
 
-	log.Printf("Final Player Score is: %d", finalPlayerScore)
+		games, err = guide.Play(rules, in)
 
-}
+		if err != nil {
+			log.Fatalf("Error playing guide: %v", err)
+			return
+		}
 
-const (
-	OpponentPlayerMoveDistance int8 = 23
-	Rock                            = 65
-	WinPoints                       = 6
-	DrawPoints                      = 3
-	OpponentMoveLineIndex           = 0
-	PlayerMoveLineIndex             = 2
-)
+		log.Printf("Final Player Score is: %d", games.ComputePlayerScore())
+	}
+
+	if *analyze {
+		log.Printf("> (Analysis) How good is the guide when the %v?", in.Description())
+
+		analysis, err := guide.Analyze(rules, in)
+
+		if err != nil {
+			log.Fatalf("Error analyzing guide: %v", err)
+			return
+		}
+
+		for i, ra := range analysis.Rounds {
+			log.Printf("Round %d: opponent %v, best move %v (%d points)", i+1, rules.Names[ra.OpponentMove], rules.Names[ra.BestMove], ra.BestScore)
+		}
+
+		log.Printf("Scores: %v", analysis)
+	}
+
+	if *tournament {
+		log.Printf("> (Tournament) Which strategy scores the most over %d rounds against every other one?", *tournamentRounds)
+
+		guideBot, err := GuideBotFactory(guide, rules, in)
+
+		if err != nil {
+			log.Fatalf("Error creating guide bot: %v", err)
+			return
+		}
+
+		result := PlayTournament(rules, append(BotFactories, guideBot), *tournamentRounds, *seed)
+
+		for _, m := range result.Matches {
+			log.Printf("%v vs %v: %d/%d/%d (W/D/L), score %d-%d", m.First, m.Second, m.Wins, m.Draws, m.Losses, m.FirstScore, m.SecondScore)
+		}
+
+		for i, e := range result.Leaderboard {
+			log.Printf("%d. %v: %d points, matches %d/%d/%d (W/D/L)", i+1, e.Name, e.Score, e.MatchesWon, e.MatchesTied, e.MatchesLost)
+		}
+	}
+
+	if *predict {
+		log.Printf("> (Prediction) Is the opponent exploitable by an order %d Markov predictor?", *predictOrder)
+
+		report, err := guide.EvaluatePredictor(rules, in, *predictOrder, rand.New(rand.NewSource(*seed)))
+
+		if err != nil {
+			log.Fatalf("Error evaluating predictor: %v", err)
+			return
+		}
+
+		log.Printf("Predicted %d of %d opponent moves (%.1f%%, random guessing %.1f%%)", report.CorrectPredictions, len(report.Games), report.Accuracy()*100, 100/float64(rules.MovesCount()))
+		log.Printf("Predictor Score is: %d, guide (%v) Score is: %d", report.PredictorScore, in.Description(), report.GuideScore)
+	}
+
+	if *exportFilePath != "" {
+		log.Printf("> (Export) How does every round score for both players when the %v?", in.Description())
+
+		games, err = guide.Play(rules, in)
+
+		if err != nil {
+			log.Fatalf("Error playing guide: %v", err)
+			return
+		}
+
+		breakdown := games.Breakdown(rules)
+
+		if err := writeFile(*exportFilePath, breakdown, exportFormatFromPath(*exportFilePath)); err != nil {
+			log.Fatalf("Error writing file %v: %v", *exportFilePath, err)
+			return
+		}
+
+		log.Printf("Player Score is: %d, Opponent Score is: %d", breakdown.PlayerScore, breakdown.OpponentScore)
+		log.Printf("Player wins/draws/losses: %d/%d/%d", breakdown.Wins, breakdown.Draws, breakdown.Losses)
+		log.Printf("Breakdown written to %v", *exportFilePath)
+	}
+
+	if *targetScore > 0 {
+		log.Printf("> (Inference) Which meanings of the second column give a total score of %d?", *targetScore)
+
+		candidates, tried := guide.InferMappings(rules, *targetScore)
+
+		for _, c := range candidates {
+			log.Printf("Mapping %v", c)
+		}
+
+		log.Printf("%d of %d mappings give a score of %d", len(candidates), tried, *targetScore)
+	}
+
+}
 
 type Game struct {
-	PlayerMove   int8
-	OpponentMove int8
+	PlayerMove   Move
+	OpponentMove Move
 	Score        []int
 }
 
 type Games []Game
 
-func lineToGame(l string) Game {
-	opponentMove := int8(l[OpponentMoveLineIndex])
-	playerMove := int8(l[PlayerMoveLineIndex]) - OpponentPlayerMoveDistance
-	gameScore := computeScore(playerMove, opponentMove)
+// A line of the strategy guide, the meaning of Column depends on the
+// Interpretation used to play it
+type Round struct {
+	OpponentMove Move
+	Column       string
+}
 
-	return Game{
-		PlayerMove:   playerMove,
-		OpponentMove: opponentMove,
-		Score:        gameScore,
+type Guide []Round
+
+func lineToRound(l string, r Rules) (Round, error) {
+	columns := strings.Fields(l)
+	if len(columns) != 2 {
+		return Round{}, fmt.Errorf("expected 2 columns in %q", l)
 	}
-}
 
-func computeScore(pm int8, om int8) []int {
-	score := []int{int(pm - (Rock - 1)), int(om - (Rock - 1))}
+	opponentMove, ok := r.OpponentLetters[columns[0]]
+	if !ok {
+		return Round{}, fmt.Errorf("unknown opponent move %q", columns[0])
+	}
+
+	return Round{
+		OpponentMove: opponentMove,
+		Column:       columns[1],
+	}, nil
+}
 
-	movesDiff := pm - om
+func newGame(pm Move, om Move, r Rules) Game {
+	return Game{
+		PlayerMove:   pm,
+		OpponentMove: om,
+		Score:        r.computeScore(pm, om),
+	}
+}
 
-	// scissors - rock = 2
-	// paper/scissors - rock/paper = 1
-	// rock/paper/scissors - rock/paper/scissors = 0
-	// rock/paper - paper/scissors = -1
-	// rock - scissors = -2
+func (g Guide) Play(r Rules, in Interpretation) (Games, error) {
+	result := make([]Game, len(g))
 
-	switch movesDiff {
-	case 2:
-		score[1] += WinPoints
-	case 1:
-		score[0] += WinPoints
-	case 0:
-		score[0] += DrawPoints
-		score[1] += DrawPoints
-	case -1:
-		score[1] += WinPoints
-	case -2:
-		score[0] += WinPoints
+	for i, round := range g {
+		pm, err := in.PlayerMove(r, round)
+		if err != nil {
+			return nil, fmt.Errorf("round %d: %v", i+1, err)
+		}
+		result[i] = newGame(pm, round.OpponentMove, r)
 	}
 
-	return score
+	return result, nil
 }
 
 func (gs Games) ComputePlayerScore() uint {
@@ -111,68 +246,34 @@ func (gs Games) ComputePlayerScore() uint {
 	return sum
 }
 
-func parseFile(filePath string) (Games, error) {
+func parseFile(filePath string, r Rules) (Guide, error) {
 	lines, err := getFileLines(filePath)
 
 	if err != nil {
 		return nil, err
 	}
 
-	result := make([]Game, len(lines))
+	result := make([]Round, len(lines))
 
 	for i, line := range lines {
-		result[i] = lineToGame(line)
+		round, err := lineToRound(line, r)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		result[i] = round
 	}
 
 	return result, nil
 }
 
-func parseFile2(filePath string) (Games, error) {
-	lines, err := getFileLines(filePath)
-
+func writeFile(filePath string, b ScoreBreakdown, format string) error {
+	file, err := os.Create(filePath)
 	if err != nil {
-		return nil, err
-	}
-
-	lines = convertLinesInABC(lines)
-
-	lines = convertLinesToXYZ(lines)
-
-	result := make([]Game, len(lines))
-
-	for i, line := range lines {
-		result[i] = lineToGame(line)
+		return err
 	}
+	defer file.Close()
 
-	return result, nil
-}
-
-func convertLinesInABC(lines []string) []string {
-	mapping := make(map[string]string)
-	mapping["A X"] = "C"
-	mapping["A Y"] = "A"
-	mapping["A Z"] = "B"
-	mapping["B X"] = "A"
-	mapping["B Y"] = "B"
-	mapping["B Z"] = "C"
-	mapping["C X"] = "B"
-	mapping["C Y"] = "C"
-	mapping["C Z"] = "A"
-	result := make([]string, len(lines))
-	for i, line := range lines {
-		newValue := mapping[strings.TrimSpace(line)]
-		result[i] = fmt.Sprintf("%c %v", line[OpponentMoveLineIndex], newValue)
-	}
-	return result
-}
-
-func convertLinesToXYZ(lines []string) []string {
-	result := make([]string, len(lines))
-	for i, line := range lines {
-		newValue := int8(line[PlayerMoveLineIndex]) + OpponentPlayerMoveDistance
-		result[i] = fmt.Sprintf("%c %c", line[OpponentMoveLineIndex], newValue)
-	}
-	return result
+	return b.Write(file, format)
 }
 
 func getFileLines(filePath string) ([]string, error) {
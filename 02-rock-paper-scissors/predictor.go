@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// Order-k Markov chain predicting the next move of a sequence from the moves
+// that followed the last k moves before. Contexts never seen back off to the
+// last k-1 moves, down to the overall move frequencies
+type MarkovPredictor struct {
+	Order      int
+	movesCount int
+	history    []Move
+	counts     map[string][]int // last 0..Order moves -> count of every next move
+}
+
+func NewMarkovPredictor(order int, movesCount int) *MarkovPredictor {
+	return &MarkovPredictor{
+		Order:      order,
+		movesCount: movesCount,
+		counts:     make(map[string][]int),
+	}
+}
+
+func markovContext(moves []Move) string {
+	var sb strings.Builder
+	for _, m := range moves {
+		sb.WriteByte(byte('A' + m))
+	}
+	return sb.String()
+}
+
+func (p *MarkovPredictor) Observe(m Move) {
+	for k := 0; k <= p.Order && k <= len(p.history); k++ {
+		context := markovContext(p.history[len(p.history)-k:])
+		counts, ok := p.counts[context]
+		if !ok {
+			counts = make([]int, p.movesCount)
+			p.counts[context] = counts
+		}
+		counts[m]++
+	}
+	p.history = append(p.history, m)
+}
+
+// The most frequent next move of the longest context seen before, a random
+// move when nothing was observed yet
+func (p *MarkovPredictor) Predict(random *rand.Rand) Move {
+	for k := p.Order; k >= 0; k-- {
+		if k > len(p.history) {
+			continue
+		}
+		if counts, ok := p.counts[markovContext(p.history[len(p.history)-k:])]; ok {
+			return mostLikely(counts, random)
+		}
+	}
+	return Move(random.Intn(p.movesCount))
+}
+
+type PredictionReport struct {
+	Order              int
+	Games              Games // played countering the predicted opponent moves
+	CorrectPredictions int
+	PredictorScore     uint
+	GuideScore         uint
+}
+
+func (pr PredictionReport) Accuracy() float64 {
+	if len(pr.Games) == 0 {
+		return 0
+	}
+	return float64(pr.CorrectPredictions) / float64(len(pr.Games))
+}
+
+// Plays the guide opponent moves online: every round the predictor guesses the
+// opponent move from the previous ones only and the counter move is played
+func (g Guide) EvaluatePredictor(r Rules, in Interpretation, order int, random *rand.Rand) (PredictionReport, error) {
+	if order < 0 {
+		return PredictionReport{}, fmt.Errorf("invalid predictor order: %d", order)
+	}
+
+	guideGames, err := g.Play(r, in)
+	if err != nil {
+		return PredictionReport{}, err
+	}
+
+	result := PredictionReport{
+		Order:      order,
+		Games:      make(Games, len(g)),
+		GuideScore: guideGames.ComputePlayerScore(),
+	}
+
+	predictor := NewMarkovPredictor(order, r.MovesCount())
+
+	for i, round := range g {
+		predicted := predictor.Predict(random)
+		if predicted == round.OpponentMove {
+			result.CorrectPredictions++
+		}
+
+		result.Games[i] = newGame(counterMove(r, predicted, random), round.OpponentMove, r)
+		predictor.Observe(round.OpponentMove)
+	}
+
+	result.PredictorScore = result.Games.ComputePlayerScore()
+
+	return result, nil
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	WinPoints  = 6
+	DrawPoints = 3
+	LossPoints = 0
+)
+
+type Move int // index in Rules.Names
+
+type Outcome int
+
+const (
+	Loss Outcome = iota
+	Draw
+	Win
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case Loss:
+		return "loss"
+	case Draw:
+		return "draw"
+	case Win:
+		return "win"
+	}
+	return fmt.Sprintf("Outcome(%d)", int(o))
+}
+
+// The moves of a hand game, which move beats which, how each one scores and how
+// the strategy guide letters map to them
+type Rules struct {
+	Names       []string
+	ShapeScores []int
+	Beats       [][]bool // Beats[a][b] - move a beats move b
+
+	WinPoints  int
+	DrawPoints int
+	LossPoints int
+
+	OpponentLetters map[string]Move
+	PlayerLetters   map[string]Move
+	OutcomeLetters  map[string]Outcome
+}
+
+// Rock (A/X), Paper (B/Y) and Scissors (C/Z) scoring 1, 2 and 3
+func DefaultRules() Rules {
+	return CyclicRules(
+		[]string{"Rock", "Paper", "Scissors"},
+		[]string{"A", "B", "C"},
+		[]string{"X", "Y", "Z"},
+	)
+}
+
+// Odd-sized cyclic tournament where every move beats the (n-1)/2 moves before
+// it (wrapping around) and scores its position plus one, e.g. Rock, Spock,
+// Paper, Lizard, Scissors for Rock-Paper-Scissors-Lizard-Spock
+func CyclicRules(names []string, opponentLetters []string, playerLetters []string) Rules {
+	n := len(names)
+	r := Rules{
+		Names:           names,
+		ShapeScores:     make([]int, n),
+		Beats:           make([][]bool, n),
+		WinPoints:       WinPoints,
+		DrawPoints:      DrawPoints,
+		LossPoints:      LossPoints,
+		OpponentLetters: make(map[string]Move),
+		PlayerLetters:   make(map[string]Move),
+		OutcomeLetters:  map[string]Outcome{"X": Loss, "Y": Draw, "Z": Win},
+	}
+
+	for a := 0; a < n; a++ {
+		r.ShapeScores[a] = a + 1
+		r.Beats[a] = make([]bool, n)
+		for d := 1; d <= (n-1)/2; d++ {
+			r.Beats[a][(a-d+n)%n] = true
+		}
+	}
+
+	for i, l := range opponentLetters {
+		r.OpponentLetters[l] = Move(i)
+	}
+	for i, l := range playerLetters {
+		r.PlayerLetters[l] = Move(i)
+	}
+
+	return r
+}
+
+func (r Rules) MovesCount() int {
+	return len(r.Names)
+}
+
+func (r Rules) Outcome(pm Move, om Move) Outcome {
+	if r.Beats[pm][om] {
+		return Win
+	}
+	if r.Beats[om][pm] {
+		return Loss
+	}
+	return Draw
+}
+
+func (r Rules) outcomePoints(o Outcome) int {
+	switch o {
+	case Win:
+		return r.WinPoints
+	case Draw:
+		return r.DrawPoints
+	}
+	return r.LossPoints
+}
+
+// Score of the round for the player (index 0) and the opponent (index 1)
+func (r Rules) computeScore(pm Move, om Move) []int {
+	return []int{
+		r.ShapeScores[pm] + r.outcomePoints(r.Outcome(pm, om)),
+		r.ShapeScores[om] + r.outcomePoints(r.Outcome(om, pm)),
+	}
+}
+
+// The move giving the player the wanted outcome against om, the highest
+// scoring one when several do
+func (r Rules) MoveForOutcome(om Move, o Outcome) (Move, bool) {
+	best := Move(-1)
+	for m := Move(0); int(m) < r.MovesCount(); m++ {
+		if r.Outcome(m, om) != o {
+			continue
+		}
+		if best == -1 || r.ShapeScores[m] > r.ShapeScores[best] {
+			best = m
+		}
+	}
+	return best, best != -1
+}
+
+func (r Rules) Validate() error {
+	n := r.MovesCount()
+	if n == 0 {
+		return fmt.Errorf("no moves")
+	}
+	if len(r.ShapeScores) != n || len(r.Beats) != n {
+		return fmt.Errorf("expected %d shape scores and beats rows", n)
+	}
+	for a := 0; a < n; a++ {
+		if len(r.Beats[a]) != n {
+			return fmt.Errorf("expected %d beats columns for %v", n, r.Names[a])
+		}
+		if r.Beats[a][a] {
+			return fmt.Errorf("%v cannot beat itself", r.Names[a])
+		}
+		for b := 0; b < n; b++ {
+			if r.Beats[a][b] && r.Beats[b][a] {
+				return fmt.Errorf("%v and %v cannot beat each other", r.Names[a], r.Names[b])
+			}
+		}
+	}
+	for l, m := range r.OpponentLetters {
+		if int(m) < 0 || int(m) >= n {
+			return fmt.Errorf("opponent letter %v maps to an unknown move", l)
+		}
+	}
+	for l, m := range r.PlayerLetters {
+		if int(m) < 0 || int(m) >= n {
+			return fmt.Errorf("player letter %v maps to an unknown move", l)
+		}
+	}
+	return nil
+}
+
+type rulesFile struct {
+	Moves []struct {
+		Name           string `json:"name"`
+		Score          *int   `json:"score"`
+		OpponentLetter string `json:"opponentLetter"`
+		PlayerLetter   string `json:"playerLetter"`
+	} `json:"moves"`
+	Beats          map[string][]string `json:"beats"`
+	OutcomeLetters map[string]string   `json:"outcomeLetters"`
+	WinPoints      *int                `json:"winPoints"`
+	DrawPoints     *int                `json:"drawPoints"`
+	LossPoints     *int                `json:"lossPoints"`
+}
+
+// Loads rules from a json file. Missing beats make the game cyclic in the
+// order of the moves, missing scores and points keep the CyclicRules ones
+func LoadRules(filePath string) (Rules, error) {
+	rawBytes, err := os.ReadFile(filePath)
+	if err != nil {
+		return Rules{}, err
+	}
+
+	var rf rulesFile
+	if err := json.Unmarshal(rawBytes, &rf); err != nil {
+		return Rules{}, err
+	}
+
+	names := make([]string, len(rf.Moves))
+	opponentLetters := make([]string, len(rf.Moves))
+	playerLetters := make([]string, len(rf.Moves))
+	for i, m := range rf.Moves {
+		names[i] = m.Name
+		opponentLetters[i] = m.OpponentLetter
+		playerLetters[i] = m.PlayerLetter
+	}
+
+	r := CyclicRules(names, opponentLetters, playerLetters)
+
+	moveByName := make(map[string]Move)
+	for i, m := range rf.Moves {
+		moveByName[strings.ToLower(m.Name)] = Move(i)
+		if m.Score != nil {
+			r.ShapeScores[i] = *m.Score
+		}
+	}
+
+	if rf.Beats != nil {
+		for a := range r.Beats {
+			r.Beats[a] = make([]bool, len(names))
+		}
+		for winner, losers := range rf.Beats {
+			w, ok := moveByName[strings.ToLower(winner)]
+			if !ok {
+				return Rules{}, fmt.Errorf("unknown move %v", winner)
+			}
+			for _, loser := range losers {
+				l, ok := moveByName[strings.ToLower(loser)]
+				if !ok {
+					return Rules{}, fmt.Errorf("unknown move %v", loser)
+				}
+				r.Beats[w][l] = true
+			}
+		}
+	}
+
+	if rf.OutcomeLetters != nil {
+		r.OutcomeLetters = make(map[string]Outcome)
+		for l, o := range rf.OutcomeLetters {
+			switch strings.ToLower(o) {
+			case "loss", "lose":
+				r.OutcomeLetters[l] = Loss
+			case "draw":
+				r.OutcomeLetters[l] = Draw
+			case "win":
+				r.OutcomeLetters[l] = Win
+			default:
+				return Rules{}, fmt.Errorf("unknown outcome %v", o)
+			}
+		}
+	}
+
+	if rf.WinPoints != nil {
+		r.WinPoints = *rf.WinPoints
+	}
+	if rf.DrawPoints != nil {
+		r.DrawPoints = *rf.DrawPoints
+	}
+	if rf.LossPoints != nil {
+		r.LossPoints = *rf.LossPoints
+	}
+
+	return r, r.Validate()
+}
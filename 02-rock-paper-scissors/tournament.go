@@ -0,0 +1,232 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// A player of the tournament, it is told both moves after every round
+type Bot interface {
+	NextMove(random *rand.Rand) Move
+	Observe(own Move, opponent Move)
+}
+
+type BotFactory struct {
+	Name string
+	New  func(r Rules) Bot
+}
+
+// The bots available to every tournament, the guide bot is added by
+// GuideBotFactory as it needs a parsed guide. always-rock plays the first
+// move of the rules, Rock in the default ones
+var BotFactories = []BotFactory{
+	{Name: "always-rock", New: func(r Rules) Bot { return &alwaysBot{move: 0} }},
+	{Name: "cycle", New: func(r Rules) Bot { return &cycleBot{rules: r} }},
+	{Name: "copy-last", New: func(r Rules) Bot { return &copyLastBot{} }},
+	{Name: "random", New: func(r Rules) Bot { return &randomBot{rules: r} }},
+	{Name: "frequency-counter", New: func(r Rules) Bot { return newFrequencyBot(r) }},
+	{Name: "markov", New: func(r Rules) Bot { return &predictorBot{rules: r, predictor: NewMarkovPredictor(1, r.MovesCount())} }},
+}
+
+// Plays the moves of the guide, starting over when it runs out of rounds
+func GuideBotFactory(g Guide, r Rules, in Interpretation) (BotFactory, error) {
+	games, err := g.Play(r, in)
+	if err != nil {
+		return BotFactory{}, err
+	}
+	if len(games) == 0 {
+		return BotFactory{}, fmt.Errorf("empty guide")
+	}
+
+	return BotFactory{
+		Name: "guide",
+		New:  func(r Rules) Bot { return &guideBot{games: games} },
+	}, nil
+}
+
+type alwaysBot struct {
+	move Move
+}
+
+func (b *alwaysBot) NextMove(random *rand.Rand) Move { return b.move }
+
+func (b *alwaysBot) Observe(own Move, opponent Move) {}
+
+type cycleBot struct {
+	rules Rules
+	round int
+}
+
+func (b *cycleBot) NextMove(random *rand.Rand) Move {
+	return Move(b.round % b.rules.MovesCount())
+}
+
+func (b *cycleBot) Observe(own Move, opponent Move) { b.round++ }
+
+type copyLastBot struct {
+	last Move
+}
+
+func (b *copyLastBot) NextMove(random *rand.Rand) Move { return b.last }
+
+func (b *copyLastBot) Observe(own Move, opponent Move) { b.last = opponent }
+
+type randomBot struct {
+	rules Rules
+}
+
+func (b *randomBot) NextMove(random *rand.Rand) Move {
+	return Move(random.Intn(b.rules.MovesCount()))
+}
+
+func (b *randomBot) Observe(own Move, opponent Move) {}
+
+type guideBot struct {
+	games Games
+	round int
+}
+
+func (b *guideBot) NextMove(random *rand.Rand) Move {
+	return b.games[b.round%len(b.games)].PlayerMove
+}
+
+func (b *guideBot) Observe(own Move, opponent Move) { b.round++ }
+
+// Counters the move the opponent played most so far
+type frequencyBot struct {
+	rules  Rules
+	counts []int
+}
+
+func newFrequencyBot(r Rules) *frequencyBot {
+	return &frequencyBot{rules: r, counts: make([]int, r.MovesCount())}
+}
+
+func (b *frequencyBot) NextMove(random *rand.Rand) Move {
+	return counterMove(b.rules, mostLikely(b.counts, random), random)
+}
+
+func (b *frequencyBot) Observe(own Move, opponent Move) { b.counts[opponent]++ }
+
+// Counters the move a predictor expects from the opponent
+type predictorBot struct {
+	rules     Rules
+	predictor *MarkovPredictor
+}
+
+func (b *predictorBot) NextMove(random *rand.Rand) Move {
+	return counterMove(b.rules, b.predictor.Predict(random), random)
+}
+
+func (b *predictorBot) Observe(own Move, opponent Move) { b.predictor.Observe(opponent) }
+
+// The move beating the expected one, a random one if none does
+func counterMove(r Rules, expected Move, random *rand.Rand) Move {
+	if m, ok := r.MoveForOutcome(expected, Win); ok {
+		return m
+	}
+	return Move(random.Intn(r.MovesCount()))
+}
+
+// Index of the highest count, ties broken at random
+func mostLikely(counts []int, random *rand.Rand) Move {
+	var best []Move
+	for m, c := range counts {
+		if len(best) == 0 || c > counts[best[0]] {
+			best = []Move{Move(m)}
+		} else if c == counts[best[0]] {
+			best = append(best, Move(m))
+		}
+	}
+	return best[random.Intn(len(best))]
+}
+
+type MatchResult struct {
+	First, Second           string
+	Wins, Draws, Losses     int // rounds, from the first bot point of view
+	FirstScore, SecondScore int
+}
+
+type LeaderboardEntry struct {
+	Name                                 string
+	Score                                int
+	MatchesWon, MatchesTied, MatchesLost int
+}
+
+type TournamentResult struct {
+	Matches     []MatchResult
+	Leaderboard []LeaderboardEntry // best first
+}
+
+// Every bot plays every other bot for the given number of rounds. Each match
+// gets its own random source derived from seed, so results are reproducible
+func PlayTournament(r Rules, factories []BotFactory, rounds int, seed int64) TournamentResult {
+	entries := make([]LeaderboardEntry, len(factories))
+	for i, f := range factories {
+		entries[i].Name = f.Name
+	}
+
+	var result TournamentResult
+	match := int64(0)
+
+	for i := 0; i < len(factories); i++ {
+		for j := i + 1; j < len(factories); j++ {
+			random := rand.New(rand.NewSource(seed + match))
+			match++
+
+			mr := playMatch(r, factories[i], factories[j], rounds, random)
+			result.Matches = append(result.Matches, mr)
+
+			entries[i].Score += mr.FirstScore
+			entries[j].Score += mr.SecondScore
+			switch {
+			case mr.FirstScore > mr.SecondScore:
+				entries[i].MatchesWon++
+				entries[j].MatchesLost++
+			case mr.FirstScore < mr.SecondScore:
+				entries[i].MatchesLost++
+				entries[j].MatchesWon++
+			default:
+				entries[i].MatchesTied++
+				entries[j].MatchesTied++
+			}
+		}
+	}
+
+	sort.SliceStable(entries, func(a, b int) bool {
+		return entries[a].Score > entries[b].Score
+	})
+	result.Leaderboard = entries
+
+	return result
+}
+
+func playMatch(r Rules, first BotFactory, second BotFactory, rounds int, random *rand.Rand) MatchResult {
+	a := first.New(r)
+	b := second.New(r)
+	result := MatchResult{First: first.Name, Second: second.Name}
+
+	for i := 0; i < rounds; i++ {
+		am := a.NextMove(random)
+		bm := b.NextMove(random)
+
+		score := r.computeScore(am, bm)
+		result.FirstScore += score[0]
+		result.SecondScore += score[1]
+
+		switch r.Outcome(am, bm) {
+		case Win:
+			result.Wins++
+		case Draw:
+			result.Draws++
+		case Loss:
+			result.Losses++
+		}
+
+		a.Observe(am, bm)
+		b.Observe(bm, am)
+	}
+
+	return result
+}
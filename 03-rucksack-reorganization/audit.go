@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+const (
+	AuditFormatText = "text"
+	AuditFormatJSON = "json"
+)
+
+// An item type found in both compartments of a rucksack
+type MisplacedItem struct {
+	Type                        string `json:"type"`
+	Priority                    int    `json:"priority"`
+	FirstCompartementPositions  []int  `json:"firstCompartementPositions"`  // 1-based columns of the line
+	SecondCompartementPositions []int  `json:"secondCompartementPositions"` // 1-based columns of the line
+}
+
+func (m MisplacedItem) Copies() int {
+	return len(m.FirstCompartementPositions) + len(m.SecondCompartementPositions)
+}
+
+type RucksackFinding struct {
+	Line      int             `json:"line"`
+	Clean     bool            `json:"clean"`
+	Misplaced []MisplacedItem `json:"misplaced,omitempty"`
+}
+
+type AuditReport struct {
+	Rucksacks          []RucksackFinding `json:"rucksacks"`
+	CleanLines         []int             `json:"cleanLines"`
+	MisplacedRucksacks int               `json:"misplacedRucksacks"`
+	MisplacedItemTypes int               `json:"misplacedItemTypes"` // summed over rucksacks
+	MisplacedCopies    int               `json:"misplacedCopies"`
+}
+
+func (r Rucksack) Audit(line int) RucksackFinding {
+	finding := RucksackFinding{
+		Line:  line,
+		Clean: len(r.SharedItems) == 0,
+	}
+
+	for _, shared := range r.SharedItems {
+		mi := MisplacedItem{
+			Type:     string(shared.Type),
+			Priority: shared.Priority,
+		}
+		for i, it := range r.FirstCompartementItems {
+			if it.Type == shared.Type {
+				mi.FirstCompartementPositions = append(mi.FirstCompartementPositions, i+1)
+			}
+		}
+		offset := len(r.FirstCompartementItems)
+		for i, it := range r.SecondCompartementItems {
+			if it.Type == shared.Type {
+				mi.SecondCompartementPositions = append(mi.SecondCompartementPositions, offset+i+1)
+			}
+		}
+		finding.Misplaced = append(finding.Misplaced, mi)
+	}
+
+	return finding
+}
+
+func (rs Rucksacks) Audit() AuditReport {
+	report := AuditReport{
+		Rucksacks:  make([]RucksackFinding, len(rs)),
+		CleanLines: []int{},
+	}
+
+	for i, r := range rs {
+		finding := r.Audit(i + 1)
+		report.Rucksacks[i] = finding
+
+		if finding.Clean {
+			report.CleanLines = append(report.CleanLines, finding.Line)
+			continue
+		}
+
+		report.MisplacedRucksacks++
+		report.MisplacedItemTypes += len(finding.Misplaced)
+		for _, mi := range finding.Misplaced {
+			report.MisplacedCopies += mi.Copies()
+		}
+	}
+
+	return report
+}
+
+func (a AuditReport) Write(w io.Writer, format string) error {
+	switch format {
+	case AuditFormatText:
+		return a.WriteText(w)
+	case AuditFormatJSON:
+		return a.WriteJSON(w)
+	}
+	return fmt.Errorf("unknown audit format: %v", format)
+}
+
+func (a AuditReport) WriteText(w io.Writer) error {
+	for _, f := range a.Rucksacks {
+		if f.Clean {
+			continue
+		}
+		for _, mi := range f.Misplaced {
+			_, err := fmt.Fprintf(w, "Line %d: %v (priority %d) %d copies, first compartement at %v, second compartement at %v\n",
+				f.Line, mi.Type, mi.Priority, mi.Copies(), mi.FirstCompartementPositions, mi.SecondCompartementPositions)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "Clean rucksacks (%d): %v\nRucksacks with misplaced items: %d, misplaced item types: %d, misplaced copies: %d\n",
+		len(a.CleanLines), a.CleanLines, a.MisplacedRucksacks, a.MisplacedItemTypes, a.MisplacedCopies)
+	return err
+}
+
+func (a AuditReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(a)
+}
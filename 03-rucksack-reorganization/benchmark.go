@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+)
+
+const (
+	ItemTypes          = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	BenchmarkMinLength = 4
+)
+
+// Rucksacks in groups of three following the puzzle rules: a single item type
+// in both compartments of each rucksack and a single badge per group. length
+// must be at least BenchmarkMinLength, room for both shared items and the badge
+func generateRucksackLines(count int, length int, random *rand.Rand) []string {
+	lines := make([]string, 0, count)
+	half := length / 2
+
+	for len(lines) < count {
+		types := []byte(ItemTypes)
+		random.Shuffle(len(types), func(i, j int) { types[i], types[j] = types[j], types[i] })
+
+		badge := types[0]
+		pools := [][]byte{types[1:18], types[18:35], types[35:52]}
+
+		for _, pool := range pools {
+			shared := pool[0]
+			first := pool[1:9]
+			second := pool[9:17]
+
+			items := make([]byte, 2*half)
+			for i := 0; i < half; i++ {
+				items[i] = first[random.Intn(len(first))]
+				items[half+i] = second[random.Intn(len(second))]
+			}
+
+			firstShared := random.Intn(half)
+			secondShared := half + random.Intn(half)
+			items[firstShared] = shared
+			items[secondShared] = shared
+
+			// anywhere but over a shared item
+			badgePosition := random.Intn(2*half - 2)
+			if badgePosition >= firstShared {
+				badgePosition++
+			}
+			if badgePosition >= secondShared {
+				badgePosition++
+			}
+			items[badgePosition] = badge
+
+			lines = append(lines, string(items))
+		}
+	}
+
+	return lines[:count]
+}
+
+// The previous implementations, comparing every pair of items
+func naiveSharedItems(fci []Item, sci []Item) []Item {
+	var sharedItemTypes []Item
+
+	for _, fit := range fci {
+		for _, sit := range sci {
+			if fit.Type == sit.Type {
+				sharedItemTypes = append(sharedItemTypes, fit)
+			}
+		}
+	}
+
+	return sharedItemTypes
+}
+
+func naiveBadgePriorityValue(r1 Rucksack, r2 Rucksack, r3 Rucksack) int {
+	r1Items := append(append([]Item{}, r1.FirstCompartementItems...), r1.SecondCompartementItems...)
+	r2Items := append(append([]Item{}, r2.FirstCompartementItems...), r2.SecondCompartementItems...)
+	r3Items := append(append([]Item{}, r3.FirstCompartementItems...), r3.SecondCompartementItems...)
+	for _, r1it := range r1Items {
+		for _, r2it := range r2Items {
+			for _, r3it := range r3Items {
+				if r1it.Type == r2it.Type && r2it.Type == r3it.Type {
+					return r1it.Priority
+				}
+			}
+		}
+	}
+
+	return 0
+}
+
+// Solves both puzzles on generated rucksacks with the naive and the bitset
+// implementations, checking they agree
+func runBenchmark(count int, length int, seed int64) error {
+	lines := generateRucksackLines(count, length, rand.New(rand.NewSource(seed)))
+	priorities := DefaultPriorityTable()
+
+	log.Printf("> (Benchmark) %d generated rucksacks of %d items", len(lines), length)
+
+	start := time.Now()
+	naiveShared, naiveBadges := uint(0), uint(0)
+	naiveRucksacks := make(Rucksacks, len(lines))
+	for i, line := range lines {
+		items, err := itemTypesToItems(line, priorities)
+		if err != nil {
+			return err
+		}
+		r := Rucksack{FirstCompartementItems: items[:len(items)/2], SecondCompartementItems: items[len(items)/2:]}
+		r.SharedItems = naiveSharedItems(r.FirstCompartementItems, r.SecondCompartementItems)
+		naiveShared += uint(r.FirstSharedItemTypePriority())
+		naiveRucksacks[i] = r
+	}
+	for i := 0; i < len(naiveRucksacks)-2; i = i + 3 {
+		naiveBadges += uint(naiveBadgePriorityValue(naiveRucksacks[i], naiveRucksacks[i+1], naiveRucksacks[i+2]))
+	}
+	naiveElapsed := time.Since(start)
+
+	start = time.Now()
+	rucksacks := make(Rucksacks, len(lines))
+	for i, line := range lines {
+		r, err := lineToRucksack(line, priorities)
+		if err != nil {
+			return err
+		}
+		rucksacks[i] = r
+	}
+	bitsetShared := rucksacks.ComputeSumOfFirstSharedItemTypePriorityValues()
+	bitsetBadges, _ := rucksacks.ComputeSumOfGroupsBadgesPriorityValues(3)
+	bitsetElapsed := time.Since(start)
+
+	log.Printf("Naive:  shared %d, badges %d - %s", naiveShared, naiveBadges, naiveElapsed)
+	log.Printf("Bitset: shared %d, badges %d - %s", bitsetShared, bitsetBadges, bitsetElapsed)
+
+	if naiveShared != bitsetShared || naiveBadges != bitsetBadges {
+		return fmt.Errorf("the implementations disagree")
+	}
+
+	log.Printf("Speedup: %.1fx", float64(naiveElapsed)/float64(bitsetElapsed))
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import "math/bits"
+
+// Groups of rucksack indexes, each group sharing exactly one item type
+type Grouping [][]int
+
+type GroupingDiscovery struct {
+	Solutions        []Grouping // in search order, the consecutive grouping first when valid
+	Exhausted        bool       // every grouping was looked at, Solutions holds them all
+	Nodes            int
+	ConsecutiveValid bool
+}
+
+// True when the consecutive grouping is known to be the only valid one
+func (d GroupingDiscovery) ConsecutiveIsUnique() bool {
+	return d.ConsecutiveValid && d.Exhausted && len(d.Solutions) == 1
+}
+
+// Looks for partitions of the rucksacks in groups of groupSize where every
+// group has exactly one common item type (its badge), stopping after
+// maxSolutions partitions or maxNodes search steps
+func (rs Rucksacks) DiscoverGroupings(groupSize int, maxSolutions int, maxNodes int) GroupingDiscovery {
+	search := groupingSearch{
+		sets:         groupingSets(rs),
+		groupSize:    groupSize,
+		assigned:     make([]bool, len(rs)),
+		maxSolutions: maxSolutions,
+		maxNodes:     maxNodes,
+	}
+
+	result := GroupingDiscovery{
+		ConsecutiveValid: groupSize > 0 && len(rs)%groupSize == 0,
+	}
+
+	if groupSize < 1 || len(rs)%groupSize != 0 {
+		result.Exhausted = true
+		return result
+	}
+
+	for i := 0; i < len(rs) && result.ConsecutiveValid; i += groupSize {
+		common := search.sets[i]
+		for j := i + 1; j < i+groupSize; j++ {
+			common = andWords(common, search.sets[j])
+		}
+		result.ConsecutiveValid = countWords(common) == 1
+	}
+
+	search.solve()
+
+	result.Solutions = search.solutions
+	result.Nodes = search.nodes
+	result.Exhausted = !search.aborted && len(search.solutions) < maxSolutions
+
+	return result
+}
+
+type groupingSearch struct {
+	sets         [][]uint64
+	groupSize    int
+	assigned     []bool
+	groups       Grouping
+	solutions    []Grouping
+	maxSolutions int
+	maxNodes     int
+	nodes        int
+	aborted      bool
+}
+
+// Item sets of the rucksacks, all with the same number of words
+func groupingSets(rs Rucksacks) [][]uint64 {
+	width := 0
+	for _, r := range rs {
+		if w := len(r.ItemSet()); w > width {
+			width = w
+		}
+	}
+
+	result := make([][]uint64, len(rs))
+	for i, r := range rs {
+		result[i] = make([]uint64, width)
+		copy(result[i], r.ItemSet())
+	}
+	return result
+}
+
+func andWords(a []uint64, b []uint64) []uint64 {
+	result := make([]uint64, len(a))
+	for w := range a {
+		result[w] = a[w] & b[w]
+	}
+	return result
+}
+
+func countWords(a []uint64) int {
+	count := 0
+	for _, word := range a {
+		count += bits.OnesCount64(word)
+	}
+	return count
+}
+
+func (s *groupingSearch) done() bool {
+	return s.aborted || len(s.solutions) >= s.maxSolutions
+}
+
+// Groups the first rucksack not in a group yet, with rucksacks after it
+func (s *groupingSearch) solve() {
+	first := -1
+	for i, a := range s.assigned {
+		if !a {
+			first = i
+			break
+		}
+	}
+
+	if first == -1 {
+		solution := make(Grouping, len(s.groups))
+		for i, g := range s.groups {
+			solution[i] = append([]int{}, g...)
+		}
+		s.solutions = append(s.solutions, solution)
+		return
+	}
+
+	s.assigned[first] = true
+	s.extend([]int{first}, s.sets[first], first+1)
+	s.assigned[first] = false
+}
+
+func (s *groupingSearch) extend(group []int, common []uint64, from int) {
+	if len(group) == s.groupSize {
+		if countWords(common) == 1 {
+			s.groups = append(s.groups, group)
+			s.solve()
+			s.groups = s.groups[:len(s.groups)-1]
+		}
+		return
+	}
+
+	for j := from; j < len(s.sets) && !s.done(); j++ {
+		if s.assigned[j] {
+			continue
+		}
+
+		c := andWords(common, s.sets[j])
+		if countWords(c) == 0 {
+			continue
+		}
+
+		s.nodes++
+		if s.nodes > s.maxNodes {
+			s.aborted = true
+			return
+		}
+
+		s.assigned[j] = true
+		s.extend(append(group[:len(group):len(group)], j), c, j+1)
+		s.assigned[j] = false
+	}
+}
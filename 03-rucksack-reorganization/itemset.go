@@ -0,0 +1,73 @@
+package main
+
+import "math/bits"
+
+// Set of item types by their PriorityTable index, one bit per item type so
+// the whole 52 item alphabet fits in a single word
+type ItemSet []uint64
+
+func itemSetWord(i int) (int, uint64) {
+	return i / 64, uint64(1) << (uint(i) % 64)
+}
+
+func (s *ItemSet) Add(i int) {
+	w, bit := itemSetWord(i)
+	for len(*s) <= w {
+		*s = append(*s, 0)
+	}
+	(*s)[w] |= bit
+}
+
+func (s ItemSet) Has(i int) bool {
+	w, bit := itemSetWord(i)
+	return w < len(s) && s[w]&bit != 0
+}
+
+func (s ItemSet) And(o ItemSet) ItemSet {
+	n := len(s)
+	if len(o) < n {
+		n = len(o)
+	}
+	result := make(ItemSet, n)
+	for w := 0; w < n; w++ {
+		result[w] = s[w] & o[w]
+	}
+	return result
+}
+
+func (s ItemSet) Or(o ItemSet) ItemSet {
+	if len(s) < len(o) {
+		s, o = o, s
+	}
+	result := make(ItemSet, len(s))
+	copy(result, s)
+	for w := range o {
+		result[w] |= o[w]
+	}
+	return result
+}
+
+func (s ItemSet) Count() int {
+	count := 0
+	for _, word := range s {
+		count += bits.OnesCount64(word)
+	}
+	return count
+}
+
+func (s ItemSet) IsEmpty() bool {
+	return s.Count() == 0
+}
+
+// Item indexes in ascending order
+func (s ItemSet) Items() []int {
+	var result []int
+	for w, word := range s {
+		for word != 0 {
+			b := bits.TrailingZeros64(word)
+			result = append(result, w*64+b)
+			word &= word - 1
+		}
+	}
+	return result
+}
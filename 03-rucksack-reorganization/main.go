@@ -3,21 +3,59 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
+	"sort"
 	"strings"
 )
 
 func main() {
 	var inputFilePath = flag.String("inputFilePath", "./inputf.txt", "Input File")
+	var priorityTableFilePath = flag.String("priorityTableFilePath", "", "Item type priorities file (default - a-z 1 to 26, A-Z 27 to 52)")
+	var audit = flag.String("audit", "", "Write an audit of the items in both compartments to the standard output: text or json")
+	var repack = flag.Bool("repack", false, "Plan the fewest swaps leaving every item type in a single compartment")
+	var discoverGroups = flag.Bool("discoverGroups", false, "Look for other ways of grouping the rucksacks with exactly one badge per group")
+	var searchNodes = flag.Int("searchNodes", 1000000, "Search steps allowed when discovering groups")
+	var groupSize = flag.Int("groupSize", 3, "Number of Elves of every group")
+	var benchmark = flag.Int("benchmark", 0, "Compare the naive and bitset solutions on this many generated rucksacks instead of solving the input")
+	var benchmarkLength = flag.Int("benchmarkLength", 48, "Items of every generated rucksack")
+	var seed = flag.Int64("seed", 1, "Seed of the generated rucksacks")
 	flag.Parse()
 
+	if *groupSize < 1 {
+		log.Fatalf("Invalid group size %d", *groupSize)
+		return
+	}
+
+	if *benchmark > 0 {
+		if *benchmarkLength < BenchmarkMinLength {
+			log.Fatalf("Invalid benchmark length %d, at least %d items are needed", *benchmarkLength, BenchmarkMinLength)
+			return
+		}
+		if err := runBenchmark(*benchmark, *benchmarkLength, *seed); err != nil {
+			log.Fatalf("Error running benchmark: %v", err)
+		}
+		return
+	}
+
 	log.Printf("inputFilePath %v\n", *inputFilePath)
 
+	priorities := DefaultPriorityTable()
+
+	if *priorityTableFilePath != "" {
+		var err error
+		priorities, err = LoadPriorityTable(*priorityTableFilePath)
+		if err != nil {
+			log.Fatalf("Error loading priority table %v: %v", *priorityTableFilePath, err)
+			return
+		}
+	}
+
 	log.Printf("> (1st Puzzle) Find the item type that appears in both compartments of each rucksack. What is the sum of the priorities of those item types?")
 
-	rucksacks, err := parseFile(*inputFilePath)
+	rucksacks, err := parseFile(*inputFilePath, priorities)
 
 	if err != nil {
 		log.Fatalf("Error Parsing file %v: %v", *inputFilePath, err)
@@ -28,24 +66,91 @@ func main() {
 
 	log.Printf("Sum of first shared item type priorities is: %d", sumOfPriorities)
 
-	log.Printf("> (2nd Puzzle) Find the item type that corresponds to the badges of each three-Elf group. What is the sum of the priorities of those item types?")
+	log.Printf("> (2nd Puzzle) Find the item type that corresponds to the badges of each %d-Elf group. What is the sum of the priorities of those item types?", *groupSize)
+
+	sumOfBadgesPriorities, diagnostics := rucksacks.ComputeSumOfGroupsBadgesPriorityValues(*groupSize)
+
+	for _, d := range diagnostics {
+		var badges []string
+		for _, it := range d.Badges {
+			badges = append(badges, fmt.Sprintf("%c(%d)", it.Type, it.Priority))
+		}
+		log.Printf("Group %d (lines %d-%d): %v %v", d.Group, d.FirstLine, d.FirstLine+d.Size-1, d.Problem, badges)
+	}
+
+	log.Printf("Sum of badges of each %d-Elf group priorities is: %d (%d groups left out)", *groupSize, sumOfBadgesPriorities, len(diagnostics))
+
+	if *discoverGroups {
+		log.Printf("> (Groups) Is grouping consecutive rucksacks the only way to give every %d-Elf group exactly one badge?", *groupSize)
+
+		discovery := rucksacks.DiscoverGroupings(*groupSize, 2, *searchNodes)
 
-	sumOfBadgesPriorities := rucksacks.ComputeSumOfGroupsBadgesPriorityValues()
+		log.Printf("Consecutive grouping valid: %v", discovery.ConsecutiveValid)
 
-	log.Printf("Sum of badges of each three-Elf group priorities is: %d", sumOfBadgesPriorities)
+		for i, grouping := range discovery.Solutions {
+			for _, g := range grouping {
+				if g[0]%*groupSize != 0 || g[len(g)-1] != g[0]+*groupSize-1 {
+					log.Printf("Grouping %d: lines %v", i+1, linesOfGroup(g))
+				}
+			}
+		}
+
+		switch {
+		case discovery.ConsecutiveIsUnique():
+			log.Printf("The consecutive grouping is the only valid one (%d search steps)", discovery.Nodes)
+		case len(discovery.Solutions) > 0 && !discovery.ConsecutiveValid:
+			log.Printf("The consecutive grouping is not valid but the one above is (%d search steps)", discovery.Nodes)
+		case len(discovery.Solutions) > 1:
+			log.Printf("Other valid groupings exist, the groups above differ from the consecutive ones (%d search steps)", discovery.Nodes)
+		case discovery.Exhausted:
+			log.Printf("There is no valid grouping (%d search steps)", discovery.Nodes)
+		default:
+			log.Printf("No other grouping found within %d search steps", *searchNodes)
+		}
+	}
+
+	if *repack {
+		log.Printf("> (Repacking) Which swaps leave every item type in a single compartment?")
+
+		swaps, infeasible := 0, 0
+
+		for _, plan := range rucksacks.PlanRepacking() {
+			if !plan.Feasible {
+				infeasible++
+				log.Printf("Line %d: cannot be repacked with equal compartments", plan.Line)
+				continue
+			}
+			for _, s := range plan.Swaps {
+				log.Printf("Line %d: swap %c at %d with %c at %d", plan.Line, s.FirstItem.Type, s.FirstPosition, s.SecondItem.Type, s.SecondPosition)
+			}
+			swaps += len(plan.Swaps)
+		}
+
+		log.Printf("Total swaps: %d, rucksacks that cannot be repacked: %d", swaps, infeasible)
+	}
+
+	if *audit != "" {
+		log.Printf("> (Audit) Which items are in both compartments of each rucksack?")
+
+		if err := rucksacks.Audit().Write(os.Stdout, *audit); err != nil {
+			log.Fatalf("Error writing audit: %v", err)
+			return
+		}
+	}
 }
 
-const (
-	CapitalAlphabetStartByteValue                 int = 65
-	LowerAlphabetStartByteValue                   int = 97
-	SpaceBetweenCapitalAndLowerAsciiAlphabetStart int = 32
-	SpaceBetweenAsciiAlphabetEndToStart           int = 5
-)
+type Item struct {
+	Type     rune
+	Priority int
+	Index    int // in the PriorityTable, to key the item sets
+}
 
 type Rucksack struct {
-	FirstCompartementItems  []byte
-	SecondCompartementItems []byte
-	SharedItems             []byte
+	FirstCompartementItems  []Item
+	SecondCompartementItems []Item
+	SharedItems             []Item  // each shared item type once, in first compartment order
+	FirstCompartementSet    ItemSet // by item index
+	SecondCompartementSet   ItemSet // by item index
 }
 
 type Rucksacks []Rucksack
@@ -60,89 +165,171 @@ func (rs Rucksacks) ComputeSumOfFirstSharedItemTypePriorityValues() uint {
 	return sum
 }
 
-func (rs Rucksacks) ComputeSumOfGroupsBadgesPriorityValues() uint {
+// A group whose badge could not be told: incomplete, without any item type
+// common to all its rucksacks or with more than one
+type GroupDiagnostic struct {
+	Group     int // 0-based
+	FirstLine int // 1-based line of the group first rucksack
+	Size      int
+	Badges    []Item // the candidate badges
+	Problem   string
+}
+
+const (
+	ProblemIncompleteGroup = "incomplete group"
+	ProblemNoBadge         = "no badge"
+	ProblemMultipleBadges  = "multiple badges"
+)
+
+// Sums the badge priorities of every group of groupSize consecutive rucksacks,
+// groups without exactly one badge are left out of the sum and reported
+func (rs Rucksacks) ComputeSumOfGroupsBadgesPriorityValues(groupSize int) (uint, []GroupDiagnostic) {
 	sum := uint(0)
+	var diagnostics []GroupDiagnostic
 
-	for i := 0; i < len(rs)-2; i = i + 3 {
-		sum += uint(ComputeSumOfBadgesPriorityValues(rs[i], rs[i+1], rs[i+2]))
+	if groupSize < 1 {
+		return sum, diagnostics
 	}
 
-	return sum
+	for i := 0; i < len(rs); i = i + groupSize {
+		group := rs[i:minInt(i+groupSize, len(rs))]
+		diagnostic := GroupDiagnostic{
+			Group:     i / groupSize,
+			FirstLine: i + 1,
+			Size:      len(group),
+		}
+
+		if len(group) < groupSize {
+			diagnostic.Problem = ProblemIncompleteGroup
+			diagnostics = append(diagnostics, diagnostic)
+			continue
+		}
+
+		badges := ComputeGroupBadges(group)
+
+		switch len(badges) {
+		case 1:
+			sum += uint(badges[0].Priority)
+			continue
+		case 0:
+			diagnostic.Problem = ProblemNoBadge
+		default:
+			diagnostic.Problem = ProblemMultipleBadges
+		}
+
+		diagnostic.Badges = badges
+		diagnostics = append(diagnostics, diagnostic)
+	}
+
+	return sum, diagnostics
 }
 
-func ComputeSumOfBadgesPriorityValues(r1 Rucksack, r2 Rucksack, r3 Rucksack) uint8 {
+// The item types carried by every rucksack of the group, by priority
+func ComputeGroupBadges(group Rucksacks) []Item {
+	if len(group) == 0 {
+		return nil
+	}
 
-	r1Items := append(r1.FirstCompartementItems, r1.SecondCompartementItems...)
-	r2Items := append(r2.FirstCompartementItems, r2.SecondCompartementItems...)
-	r3Items := append(r3.FirstCompartementItems, r3.SecondCompartementItems...)
-	for _, r1it := range r1Items {
-		for _, r2it := range r2Items {
-			for _, r3it := range r3Items {
-				if r1it == r2it && r2it == r3it {
-					return itemTypeToPriorityValue(r1it)
-				}
+	common := group[0].ItemSet()
+	for _, r := range group[1:] {
+		common = common.And(r.ItemSet())
+	}
+
+	// the sets know the items by index, the first rucksack tells their type
+	var result []Item
+	var added ItemSet
+	for _, items := range [][]Item{group[0].FirstCompartementItems, group[0].SecondCompartementItems} {
+		for _, it := range items {
+			if common.Has(it.Index) && !added.Has(it.Index) {
+				result = append(result, it)
+				added.Add(it.Index)
 			}
 		}
 	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Priority != result[j].Priority {
+			return result[i].Priority < result[j].Priority
+		}
+		return result[i].Type < result[j].Type
+	})
+
+	return result
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
 
-	return uint8(0)
+// Item types of both compartments
+func (r Rucksack) ItemSet() ItemSet {
+	return r.FirstCompartementSet.Or(r.SecondCompartementSet)
 }
 
-func (r Rucksack) FirstSharedItemTypePriority() uint8 {
-	return itemTypeToPriorityValue(r.SharedItems[0])
+// 0 when no item type is in both compartments
+func (r Rucksack) FirstSharedItemTypePriority() int {
+	if len(r.SharedItems) == 0 {
+		return 0
+	}
+	return r.SharedItems[0].Priority
 }
 
-func lineToRucksack(l string) Rucksack {
-	items := []byte(l)
+func lineToRucksack(l string, t PriorityTable) (Rucksack, error) {
+	items, err := itemTypesToItems(l, t)
+	if err != nil {
+		return Rucksack{}, err
+	}
+
 	itemsCount := len(items)
 	firstCompartementItems := items[0 : itemsCount/2]
 	secondCompartementItems := items[itemsCount/2 : itemsCount]
-	sharedItems := computeSharedItems(firstCompartementItems, secondCompartementItems)
+	firstCompartementSet := itemsToItemSet(firstCompartementItems)
+	secondCompartementSet := itemsToItemSet(secondCompartementItems)
+	sharedItems := computeSharedItems(firstCompartementItems, firstCompartementSet.And(secondCompartementSet))
 
 	return Rucksack{
 		FirstCompartementItems:  firstCompartementItems,
 		SecondCompartementItems: secondCompartementItems,
 		SharedItems:             sharedItems,
+		FirstCompartementSet:    firstCompartementSet,
+		SecondCompartementSet:   secondCompartementSet,
+	}, nil
+}
+
+func itemsToItemSet(items []Item) ItemSet {
+	var result ItemSet
+	for _, it := range items {
+		result.Add(it.Index)
 	}
+	return result
 }
 
-func computeSharedItems(fci []byte, sci []byte) []byte {
-	var sharedItemTypes []byte
+// The items of fci in the shared set, each item type once
+func computeSharedItems(fci []Item, shared ItemSet) []Item {
+	var sharedItemTypes []Item
+	var added ItemSet
 
 	for _, fit := range fci {
-		for _, sit := range sci {
-			if fit == sit {
-				sharedItemTypes = append(sharedItemTypes, fit)
-			}
+		if shared.Has(fit.Index) && !added.Has(fit.Index) {
+			sharedItemTypes = append(sharedItemTypes, fit)
+			added.Add(fit.Index)
 		}
 	}
 
 	return sharedItemTypes
 }
 
-func itemTypeToPriorityValue(it byte) uint8 {
-	// byte(a) = 97
-	// byte(A) = 65
-	// space between ascii alphabet = 32
-	// priority(a) = byte(a) - SpaceBetweenCapitalAndLowerAsciiAlphabetStart - CapitalAlphabetStartByteValue + 1 = 97 - 32 - 65 + 1 = 1
-	// priority(b) = byte(b) - SpaceBetweenCapitalAndLowerAsciiAlphabetStart - CapitalAlphabetStartByteValue + 1 = 98 - 32 - 65 + 1 = 2
-	// ----------
-	// priority(A) = byte(A) - SpaceBetweenCapitalAndLowerAsciiAlphabetStart - SpaceBetweenAsciiAlphabetEndToStart - 1 = 65 - 32 - 5 - 1 = 27
-	// priority(B) = byte(B) - SpaceBetweenCapitalAndLowerAsciiAlphabetStart - SpaceBetweenAsciiAlphabetEndToStart - 1 = 66 - 32 - 5 - 1 = 28
-	bv := int(it)
-
-	var pv int
-
-	if bv < LowerAlphabetStartByteValue {
-		pv = bv - SpaceBetweenCapitalAndLowerAsciiAlphabetStart - SpaceBetweenAsciiAlphabetEndToStart - 1
-	} else {
-		pv = bv - SpaceBetweenCapitalAndLowerAsciiAlphabetStart - CapitalAlphabetStartByteValue + 1
+func linesOfGroup(g []int) []int {
+	result := make([]int, len(g))
+	for i, r := range g {
+		result[i] = r + 1
 	}
-
-	return uint8(pv)
+	return result
 }
 
-func parseFile(filePath string) (Rucksacks, error) {
+func parseFile(filePath string, t PriorityTable) (Rucksacks, error) {
 	lines, err := getFileLines(filePath)
 
 	if err != nil {
@@ -152,7 +339,10 @@ func parseFile(filePath string) (Rucksacks, error) {
 	result := make([]Rucksack, len(lines))
 
 	for i, line := range lines {
-		result[i] = lineToRucksack(line)
+		result[i], err = lineToRucksack(line, t)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
 	}
 
 	return result, nil
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Priority value of every item type the rucksacks may hold. Item types also
+// get a dense index, in the order they are added, so item sets stay as small
+// as the table whatever the priorities
+type PriorityTable struct {
+	priorities map[rune]int
+	indexes    map[rune]int
+}
+
+func NewPriorityTable() PriorityTable {
+	return PriorityTable{
+		priorities: make(map[rune]int),
+		indexes:    make(map[rune]int),
+	}
+}
+
+// a through z have priorities 1 through 26, A through Z 27 through 52
+func DefaultPriorityTable() PriorityTable {
+	t := NewPriorityTable()
+	t.addRange('a', 'z', 1)
+	t.addRange('A', 'Z', 27)
+	return t
+}
+
+func (t PriorityTable) Set(it rune, pv int) error {
+	if pv < 1 {
+		return fmt.Errorf("invalid priority %d for %q", pv, it)
+	}
+	if _, ok := t.priorities[it]; !ok {
+		t.indexes[it] = len(t.indexes)
+	}
+	t.priorities[it] = pv
+	return nil
+}
+
+func (t PriorityTable) addRange(from rune, to rune, firstPriority int) error {
+	for it := from; it <= to; it++ {
+		if err := t.Set(it, firstPriority+int(it-from)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t PriorityTable) Priority(it rune) (int, bool) {
+	pv, ok := t.priorities[it]
+	return pv, ok
+}
+
+// Dense index of the item type, from 0 to the number of item types
+func (t PriorityTable) Index(it rune) (int, bool) {
+	i, ok := t.indexes[it]
+	return i, ok
+}
+
+// Loads a table where every line gives the priority of its first item type,
+// the following ones getting the next priorities:
+//
+//	# comment
+//	a-z 1
+//	A-Z 27
+//	0123456789 53
+//	€ 63
+func LoadPriorityTable(filePath string) (PriorityTable, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return PriorityTable{}, err
+	}
+	defer file.Close()
+
+	t := NewPriorityTable()
+	scanner := bufio.NewScanner(file)
+
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return PriorityTable{}, fmt.Errorf("line %d: expected item types and a priority", lineNumber)
+		}
+
+		pv, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return PriorityTable{}, fmt.Errorf("line %d: invalid priority %q", lineNumber, fields[1])
+		}
+
+		itemTypes := []rune(fields[0])
+		if len(itemTypes) == 3 && itemTypes[1] == '-' && itemTypes[0] < itemTypes[2] {
+			err = t.addRange(itemTypes[0], itemTypes[2], pv)
+		} else {
+			for i, it := range itemTypes {
+				if err = t.Set(it, pv+i); err != nil {
+					break
+				}
+			}
+		}
+		if err != nil {
+			return PriorityTable{}, fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return PriorityTable{}, err
+	}
+
+	return t, nil
+}
+
+func itemTypesToItems(s string, t PriorityTable) ([]Item, error) {
+	items := make([]Item, 0, utf8.RuneCountInString(s))
+	for _, it := range s {
+		pv, ok := t.Priority(it)
+		if !ok {
+			return nil, fmt.Errorf("no priority for item type %q", it)
+		}
+		index, _ := t.Index(it)
+		items = append(items, Item{Type: it, Priority: pv, Index: index})
+	}
+	return items, nil
+}
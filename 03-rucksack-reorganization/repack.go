@@ -0,0 +1,143 @@
+package main
+
+import "sort"
+
+// Exchange of an item of the first compartment with one of the second
+type ItemSwap struct {
+	FirstPosition  int // 1-based column of the line
+	FirstItem      Item
+	SecondPosition int // 1-based column of the line
+	SecondItem     Item
+}
+
+type RepackingPlan struct {
+	Line     int
+	Feasible bool
+	Swaps    []ItemSwap
+}
+
+// Fewest swaps leaving every item type in a single compartment. Choosing
+// which item types end up in the first compartment is a subset sum over the
+// item type counts (they must fill exactly half the rucksack) minimising the
+// items that change compartment, solved by dynamic programming
+func (r Rucksack) PlanRepacking(line int) RepackingPlan {
+	plan := RepackingPlan{Line: line}
+
+	if len(r.SharedItems) == 0 {
+		plan.Feasible = true
+		return plan
+	}
+
+	type itemTypeCount struct {
+		itemType rune
+		first    int
+		second   int
+	}
+
+	var counts []itemTypeCount
+	indexes := make(map[rune]int)
+	countItem := func(it Item, first bool) {
+		i, ok := indexes[it.Type]
+		if !ok {
+			i = len(counts)
+			indexes[it.Type] = i
+			counts = append(counts, itemTypeCount{itemType: it.Type})
+		}
+		if first {
+			counts[i].first++
+		} else {
+			counts[i].second++
+		}
+	}
+	for _, it := range r.FirstCompartementItems {
+		countItem(it, true)
+	}
+	for _, it := range r.SecondCompartementItems {
+		countItem(it, false)
+	}
+
+	half := len(r.FirstCompartementItems)
+	if len(r.SecondCompartementItems) != half {
+		return plan
+	}
+
+	// cost[i][s] - fewest items moved with the first i item types, s items of
+	// them in the first compartment (-1 when impossible)
+	cost := make([][]int, len(counts)+1)
+	for i := range cost {
+		cost[i] = make([]int, half+1)
+		for s := range cost[i] {
+			cost[i][s] = -1
+		}
+	}
+	cost[0][0] = 0
+
+	for i, c := range counts {
+		total := c.first + c.second
+		for s := 0; s <= half; s++ {
+			if cost[i][s] == -1 {
+				continue
+			}
+			// all to the second compartment
+			if moved := cost[i][s] + c.first; cost[i+1][s] == -1 || moved < cost[i+1][s] {
+				cost[i+1][s] = moved
+			}
+			// all to the first compartment
+			if s+total <= half {
+				if moved := cost[i][s] + c.second; cost[i+1][s+total] == -1 || moved < cost[i+1][s+total] {
+					cost[i+1][s+total] = moved
+				}
+			}
+		}
+	}
+
+	if cost[len(counts)][half] == -1 {
+		return plan
+	}
+
+	toFirst := make(map[rune]bool)
+	for i, s := len(counts), half; i > 0; i-- {
+		c := counts[i-1]
+		total := c.first + c.second
+		if s >= total && cost[i-1][s-total] != -1 && cost[i-1][s-total]+c.second == cost[i][s] {
+			toFirst[c.itemType] = true
+			s -= total
+		}
+	}
+
+	var leaving, arriving []int // positions in the first and second compartments
+	for i, it := range r.FirstCompartementItems {
+		if !toFirst[it.Type] {
+			leaving = append(leaving, i)
+		}
+	}
+	for i, it := range r.SecondCompartementItems {
+		if toFirst[it.Type] {
+			arriving = append(arriving, i)
+		}
+	}
+
+	sort.Ints(leaving)
+	sort.Ints(arriving)
+
+	plan.Feasible = true
+	for k := range leaving {
+		f, s := leaving[k], arriving[k]
+		plan.Swaps = append(plan.Swaps, ItemSwap{
+			FirstPosition:  f + 1,
+			FirstItem:      r.FirstCompartementItems[f],
+			SecondPosition: half + s + 1,
+			SecondItem:     r.SecondCompartementItems[s],
+		})
+	}
+
+	return plan
+}
+
+func (rs Rucksacks) PlanRepacking() []RepackingPlan {
+	result := make([]RepackingPlan, len(rs))
+	for i, r := range rs {
+		result[i] = r.PlanRepacking(i + 1)
+	}
+	return result
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+)
+
+// Pairs of ranges of up to maxLength sections spread over 100 sections per
+// pair, so every elf overlaps a few others
+func generateAssignmentLines(count int, maxLength int64, random *rand.Rand) []string {
+	lines := make([]string, count)
+	span := int64(count) * 100
+
+	elf := func() SectionRange {
+		start := 1 + random.Int63n(span)
+		return SectionRange{Start: start, End: start + random.Int63n(maxLength)}
+	}
+
+	for i := range lines {
+		lines[i] = fmt.Sprintf("%v,%v", elf(), elf())
+	}
+
+	return lines
+}
+
+// Answers the cross-pair queries on generated pairs comparing every elf with
+// every other one and with the index, checking they agree
+func runBenchmark(count int, maxLength int64, seed int64) error {
+	random := rand.New(rand.NewSource(seed))
+	elvesGroup := make(ElvesGroup, count)
+
+	for i, line := range generateAssignmentLines(count, maxLength, random) {
+		group, err := lineToElfGroup(line)
+		if err != nil {
+			return fmt.Errorf("line %d: %v", i+1, err)
+		}
+		elvesGroup[i] = group
+	}
+
+	assignments := elvesGroup.Assignments()
+	queries := make([]SectionRange, 100)
+	for i := range queries {
+		start := 1 + random.Int63n(int64(count)*100)
+		queries[i] = SectionRange{Start: start, End: start + random.Int63n(maxLength)}
+	}
+
+	log.Printf("> (Benchmark) %d generated elves of up to %d sections", len(assignments), maxLength)
+
+	start := time.Now()
+	naivePairs, naiveDepth, naiveFound := 0, 0, 0
+	for i, a := range assignments {
+		depth := 0
+		for j, b := range assignments {
+			if a.Sections.Overlaps(b.Sections) && j > i {
+				naivePairs++
+			}
+			if b.Sections.ContainsSection(a.Sections.Start) {
+				depth++
+			}
+		}
+		naiveDepth = maxInt(naiveDepth, depth)
+	}
+	for _, q := range queries {
+		for _, a := range assignments {
+			if a.Sections.Overlaps(q) {
+				naiveFound++
+			}
+		}
+	}
+	naiveElapsed := time.Since(start)
+
+	start = time.Now()
+	idx := NewSectionIndex(assignments)
+	indexPairs := idx.CountOverlappingPairs()
+	indexDepth, _ := idx.MaxDepth()
+	indexFound := 0
+	for _, q := range queries {
+		indexFound += len(idx.Overlapping(q))
+	}
+	indexElapsed := time.Since(start)
+
+	log.Printf("Naive: %d overlapping pairs, max depth %d, %d elves found by %d queries - %s", naivePairs, naiveDepth, naiveFound, len(queries), naiveElapsed)
+	log.Printf("Index: %d overlapping pairs, max depth %d, %d elves found by %d queries - %s", indexPairs, indexDepth, indexFound, len(queries), indexElapsed)
+
+	if naivePairs != indexPairs || naiveDepth != indexDepth || naiveFound != indexFound {
+		return fmt.Errorf("the implementations disagree")
+	}
+
+	log.Printf("Speedup: %.1fx", float64(naiveElapsed)/float64(indexElapsed))
+
+	return nil
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// Consecutive sections covered by the same number of elves
+type CoverageSegment struct {
+	Sections SectionRange
+	Elves    int
+}
+
+// How many elves cover every section from the lowest to the highest assigned
+// one, as segments in section order
+type CoverageMap struct {
+	Sections SectionRange
+	Segments []CoverageSegment
+}
+
+type coverageEvent struct {
+	section int64
+	delta   int
+}
+
+func NewCoverageMap(assignments []ElfAssignment) CoverageMap {
+	if len(assignments) == 0 {
+		return CoverageMap{}
+	}
+
+	events := make([]coverageEvent, 0, 2*len(assignments))
+	result := CoverageMap{Sections: assignments[0].Sections}
+
+	for _, a := range assignments {
+		events = append(events, coverageEvent{section: a.Sections.Start, delta: 1})
+		// nothing follows the last section
+		if a.Sections.End < math.MaxInt64 {
+			events = append(events, coverageEvent{section: a.Sections.End + 1, delta: -1})
+		}
+		result.Sections.Start = minInt64(result.Sections.Start, a.Sections.Start)
+		result.Sections.End = maxInt64(result.Sections.End, a.Sections.End)
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].section < events[j].section
+	})
+
+	elves := 0
+	for i := 0; i < len(events); {
+		section := events[i].section
+		for ; i < len(events) && events[i].section == section; i++ {
+			elves += events[i].delta
+		}
+
+		if section > result.Sections.End {
+			break
+		}
+
+		last := len(result.Segments) - 1
+		if last >= 0 && result.Segments[last].Elves == elves {
+			continue
+		}
+		if last >= 0 {
+			result.Segments[last].Sections.End = section - 1
+		}
+		result.Segments = append(result.Segments, CoverageSegment{
+			Sections: SectionRange{Start: section, End: result.Sections.End},
+			Elves:    elves,
+		})
+	}
+
+	return result
+}
+
+// The sections between the lowest and the highest assigned ones no elf covers
+func (c CoverageMap) Gaps() []SectionRange {
+	var result []SectionRange
+	for _, s := range c.Segments {
+		if s.Elves == 0 {
+			result = append(result, s.Sections)
+		}
+	}
+	return result
+}
+
+// The segments covered by at least minElves elves
+func (c CoverageMap) Hotspots(minElves int) []CoverageSegment {
+	var result []CoverageSegment
+	for _, s := range c.Segments {
+		if s.Elves >= minElves {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func (c CoverageMap) MaxElves() int {
+	result := 0
+	for _, s := range c.Segments {
+		result = maxInt(result, s.Elves)
+	}
+	return result
+}
+
+// The sections of the group drawn like in the puzzle statement, one row per
+// elf with the last digit of its sections and dots for the others:
+//
+//	.234.....
+//	.....678.
+func (g ElfGroup) Timeline(window SectionRange) []string {
+	result := make([]string, len(g.Sections))
+	for i, r := range g.Sections {
+		result[i] = timelineRow(r, window)
+	}
+	return result
+}
+
+// The sections drawn by a timeline of r: from section 1 like in the puzzle
+// statement, or from the start of r when that is wider than width sections.
+// False when r itself is wider
+func timelineWindow(r SectionRange, width int) (SectionRange, bool) {
+	window := SectionRange{Start: 1, End: r.End}
+	if r.Start < 1 || window.Len() > uint64(width) {
+		window = r
+	}
+	return window, window.Len() <= uint64(width)
+}
+
+func timelineRow(r SectionRange, window SectionRange) string {
+	var sb strings.Builder
+	for i := uint64(0); i < window.Len(); i++ {
+		s := window.Start + int64(i)
+		if r.ContainsSection(s) {
+			sb.WriteByte(byte('0' + (s%10+10)%10))
+		} else {
+			sb.WriteByte('.')
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// The sections every elf of the group is assigned to, false when there are none
+func (g ElfGroup) CommonSections() (SectionRange, bool) {
+	if len(g.Sections) == 0 {
+		return SectionRange{}, false
+	}
+
+	result := g.Sections[0]
+	for _, r := range g.Sections[1:] {
+		var ok bool
+		if result, ok = result.Intersection(r); !ok {
+			return SectionRange{}, false
+		}
+	}
+
+	return result, true
+}
+
+// From the lowest to the highest section of the group
+func (g ElfGroup) Span() SectionRange {
+	if len(g.Sections) == 0 {
+		return SectionRange{}
+	}
+
+	result := g.Sections[0]
+	for _, r := range g.Sections[1:] {
+		result.Start = minInt64(result.Start, r.Start)
+		result.End = maxInt64(result.End, r.End)
+	}
+
+	return result
+}
+
+func (g ElfGroup) String() string {
+	ranges := make([]string, len(g.Sections))
+	for i, r := range g.Sections {
+		ranges[i] = r.String()
+	}
+	return strings.Join(ranges, ",")
+}
+
+// Sorted by start every range only needs to be compared with the furthest
+// reaching one before it
+func anyRangesOverlap(ranges []SectionRange) bool {
+	sorted := sortedRanges(ranges)
+
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Start <= sorted[i-1].End {
+			return true
+		}
+		sorted[i].End = maxInt64(sorted[i].End, sorted[i-1].End)
+	}
+
+	return false
+}
+
+// Sorted by start, and by end backwards for the same start, a range is
+// contained in an earlier one when it ends no later than all of them
+func anyRangeContainsAnother(ranges []SectionRange) bool {
+	sorted := sortedRanges(ranges)
+
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].End <= sorted[i-1].End {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sortedRanges(ranges []SectionRange) []SectionRange {
+	sorted := make([]SectionRange, len(ranges))
+	copy(sorted, ranges)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Start != sorted[j].Start {
+			return sorted[i].Start < sorted[j].Start
+		}
+		return sorted[i].End > sorted[j].End
+	})
+
+	return sorted
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// The sections of an elf, Group is the (0-based) line of its group and
+// Position its (0-based) place in the line
+type ElfAssignment struct {
+	Group    int
+	Position int
+	Sections SectionRange
+}
+
+// Every elf of the camp, in input order
+func (this ElvesGroup) Assignments() []ElfAssignment {
+	var result []ElfAssignment
+
+	for i, group := range this {
+		for j, sections := range group.Sections {
+			result = append(result, ElfAssignment{Group: i, Position: j, Sections: sections})
+		}
+	}
+
+	return result
+}
+
+// Static interval tree: the assignments sorted by start are the in-order
+// traversal of a balanced tree rooted at the middle one, maxEnd holds the
+// highest end of every subtree
+type SectionIndex struct {
+	assignments []ElfAssignment
+	maxEnd      []int64
+}
+
+func NewSectionIndex(assignments []ElfAssignment) *SectionIndex {
+	sorted := make([]ElfAssignment, len(assignments))
+	copy(sorted, assignments)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Sections.Start < sorted[j].Sections.Start
+	})
+
+	idx := &SectionIndex{assignments: sorted, maxEnd: make([]int64, len(sorted))}
+	if len(sorted) > 0 {
+		idx.build(0, len(sorted))
+	}
+
+	return idx
+}
+
+func (idx *SectionIndex) build(lo int, hi int) int64 {
+	mid := (lo + hi) / 2
+	result := idx.assignments[mid].Sections.End
+
+	if lo < mid {
+		result = maxInt64(result, idx.build(lo, mid))
+	}
+	if mid+1 < hi {
+		result = maxInt64(result, idx.build(mid+1, hi))
+	}
+
+	idx.maxEnd[mid] = result
+	return result
+}
+
+func (idx *SectionIndex) Len() int {
+	return len(idx.assignments)
+}
+
+// The elves with at least one section in r, by start section
+func (idx *SectionIndex) Overlapping(r SectionRange) []ElfAssignment {
+	var result []ElfAssignment
+	idx.overlapping(0, len(idx.assignments), r, &result)
+	return result
+}
+
+func (idx *SectionIndex) overlapping(lo int, hi int, r SectionRange, result *[]ElfAssignment) {
+	if lo >= hi {
+		return
+	}
+
+	mid := (lo + hi) / 2
+	if idx.maxEnd[mid] < r.Start {
+		return
+	}
+
+	idx.overlapping(lo, mid, r, result)
+
+	// the right subtree starts even later
+	if idx.assignments[mid].Sections.Start > r.End {
+		return
+	}
+
+	if idx.assignments[mid].Sections.Overlaps(r) {
+		*result = append(*result, idx.assignments[mid])
+	}
+
+	idx.overlapping(mid+1, hi, r, result)
+}
+
+// Sweeps the assignments by start keeping those not ended yet, each one
+// overlaps exactly the active ones when it starts. visit is called for every
+// overlapping pair (the earlier starting elf first) until it returns false
+func (idx *SectionIndex) OverlappingPairs(visit func(a ElfAssignment, b ElfAssignment) bool) {
+	active := &endHeap{}
+
+	for _, a := range idx.assignments {
+		active.popEndedBefore(a.Sections.Start)
+
+		for _, b := range *active {
+			if !visit(b, a) {
+				return
+			}
+		}
+
+		heap.Push(active, a)
+	}
+}
+
+// Number of overlapping pairs, without listing them
+func (idx *SectionIndex) CountOverlappingPairs() int {
+	active := &endHeap{}
+	count := 0
+
+	for _, a := range idx.assignments {
+		active.popEndedBefore(a.Sections.Start)
+		count += active.Len()
+		heap.Push(active, a)
+	}
+
+	return count
+}
+
+// The highest number of elves assigned to a single section and the first
+// section where it happens
+func (idx *SectionIndex) MaxDepth() (int, int64) {
+	active := &endHeap{}
+	depth, section := 0, int64(0)
+
+	for _, a := range idx.assignments {
+		active.popEndedBefore(a.Sections.Start)
+		heap.Push(active, a)
+
+		if active.Len() > depth {
+			depth, section = active.Len(), a.Sections.Start
+		}
+	}
+
+	return depth, section
+}
+
+// Min-heap of assignments by end section
+type endHeap []ElfAssignment
+
+func (h endHeap) Len() int           { return len(h) }
+func (h endHeap) Less(i, j int) bool { return h[i].Sections.End < h[j].Sections.End }
+func (h endHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *endHeap) Push(x interface{}) { *h = append(*h, x.(ElfAssignment)) }
+
+func (h *endHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+func (h *endHeap) popEndedBefore(section int64) {
+	for h.Len() > 0 && (*h)[0].Sections.End < section {
+		heap.Pop(h)
+	}
+}
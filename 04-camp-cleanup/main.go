@@ -7,50 +7,201 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
-	"strconv"
 	"strings"
 )
 
 func main() {
 	var inputFilePath = flag.String("inputFilePath", "./inputf.txt", "Input File")
+	var queryRange = flag.String("queryRange", "", "Find the elves assigned to any section of this range, like 2-4")
+	var crossPairs = flag.Bool("crossPairs", false, "Find the elves of the whole camp that overlap each other and the most elves on a single section")
+	var listPairs = flag.Int("listPairs", 20, "Overlapping elves listed by crossPairs (-1 - all)")
+	var groups = flag.Bool("groups", false, "Report the sections shared by all the elves of every line, which may hold any number of elves like 2-4,6-8,3-5")
+	var coverage = flag.Bool("coverage", false, "Report how many elves cover every section, the uncovered gaps and the hotspots")
+	var hotspotElves = flag.Int("hotspotElves", 0, "Elves on a section that make it a hotspot (0 - the most elves on a single section)")
+	var timeline = flag.Bool("timeline", false, "Draw the sections of every group like in the puzzle statement")
+	var timelineWidth = flag.Int("timelineWidth", 100, "Most sections drawn by the timeline of a group")
+	var reassign = flag.String("reassign", "", "Propose the fewest section changes that remove all the overlaps of every group: overlaps or full (only the full ones)")
+	var benchmark = flag.Int("benchmark", 0, "Compare the naive and indexed cross-pair queries on this many generated pairs instead of solving the input")
+	var benchmarkLength = flag.Int64("benchmarkLength", 1000, "Most sections of every generated elf")
+	var seed = flag.Int64("seed", 1, "Seed of the generated pairs")
 	flag.Parse()
 
+	if *benchmark > 0 {
+		if *benchmarkLength < 1 {
+			log.Fatalf("Invalid benchmark length %d", *benchmarkLength)
+			return
+		}
+		if err := runBenchmark(*benchmark, *benchmarkLength, *seed); err != nil {
+			log.Fatalf("Error running benchmark: %v", err)
+		}
+		return
+	}
+
 	log.Printf("inputFilePath %v\n", *inputFilePath)
 
 	log.Printf("> (1st Puzzle) In how many assignment pairs does one range fully contain the other?")
 
-	elvesPair, err := parseFile(*inputFilePath)
+	elvesGroup, err := parseFile(*inputFilePath)
 
 	if err != nil {
 		log.Fatalf("Error Parsing file %v: %v", *inputFilePath, err)
 		return
 	}
 
-	fullyOverlappingSectionsCount := elvesPair.ComputeNumberOfFullyOverlappingSections()
+	fullyOverlappingSectionsCount := elvesGroup.ComputeNumberOfFullyOverlappingSections()
 
 	log.Printf("The number of assignment pairs that one range fully contain the other is: %d", fullyOverlappingSectionsCount)
 
 	log.Printf("> (2nd Puzzle) In how many assignment pairs does one range fully contain the other?")
 
-	overlappingSectionsCount := elvesPair.ComputeNumberOfOverlappingSections()
+	overlappingSectionsCount := elvesGroup.ComputeNumberOfOverlappingSections()
 
 	log.Printf("The number of assignment pairs that overlap the other is: %d", overlappingSectionsCount)
+
+	if *groups {
+		log.Printf("> (Groups) In how many groups do all the elves share a section, and which ones?")
+
+		total := uint64(0)
+		for i, group := range elvesGroup {
+			common, ok := group.CommonSections()
+			if !ok {
+				continue
+			}
+			total += common.Len()
+			log.Printf("Line %d: %v share sections %v (%d)", i+1, group, common, common.Len())
+		}
+
+		log.Printf("Groups with a range fully containing another: %d", elvesGroup.ComputeNumberOfFullyOverlappingSections())
+		log.Printf("Groups with two elves sharing a section: %d", elvesGroup.ComputeNumberOfOverlappingSections())
+		log.Printf("Groups where all the elves share a section: %d (%d sections in total)", elvesGroup.ComputeNumberOfCommonSectionGroups(), total)
+	}
+
+	if *coverage {
+		log.Printf("> (Coverage) How many elves cover every section, which sections are left uncovered and which ones are crowded?")
+
+		coverageMap := NewCoverageMap(elvesGroup.Assignments())
+
+		for _, s := range coverageMap.Segments {
+			log.Printf("Sections %v: %d elves", s.Sections, s.Elves)
+		}
+
+		gaps := coverageMap.Gaps()
+		for _, g := range gaps {
+			log.Printf("Gap: sections %v (%d)", g, g.Len())
+		}
+
+		minElves := *hotspotElves
+		if minElves <= 0 {
+			minElves = coverageMap.MaxElves()
+		}
+
+		hotspots := coverageMap.Hotspots(minElves)
+		for _, h := range hotspots {
+			log.Printf("Hotspot: sections %v with %d elves", h.Sections, h.Elves)
+		}
+
+		log.Printf("Sections %v: %d gaps, %d hotspots of at least %d elves", coverageMap.Sections, len(gaps), len(hotspots), minElves)
+	}
+
+	if *timeline {
+		log.Printf("> (Timeline) Which sections does every group cover?")
+
+		// every group on the same sections when the whole camp fits
+		campWindow, campFits := timelineWindow(NewCoverageMap(elvesGroup.Assignments()).Sections, *timelineWidth)
+
+		for i, group := range elvesGroup {
+			window, fits := campWindow, campFits
+			if !fits {
+				window, fits = timelineWindow(group.Span(), *timelineWidth)
+			}
+			if !fits {
+				log.Printf("Line %d: %v does not fit in %d sections", i+1, group, *timelineWidth)
+				continue
+			}
+
+			for j, row := range group.Timeline(window) {
+				log.Printf("Line %d: %s  %v", i+1, row, group.Sections[j])
+			}
+		}
+	}
+
+	if *reassign != "" {
+		log.Printf("> (Reassignment) Which new ranges remove the %v with the fewest section changes?", reassignmentDescription(*reassign))
+
+		reassignments, err := elvesGroup.PlanReassignments(*reassign)
+
+		if err != nil {
+			log.Fatalf("Error planning reassignment: %v", err)
+			return
+		}
+
+		changed := uint64(0)
+		for _, r := range reassignments {
+			log.Printf("Line %d: %v -> %v (%d sections changed)", r.Group+1, ElfGroup{Sections: r.Before}, ElfGroup{Sections: r.After}, r.Changed)
+			changed += r.Changed
+		}
+
+		log.Printf("Groups reassigned: %d, sections changed: %d", len(reassignments), changed)
+	}
+
+	if *queryRange == "" && !*crossPairs {
+		return
+	}
+
+	idx := NewSectionIndex(elvesGroup.Assignments())
+
+	if *queryRange != "" {
+		r, err := parseSectionRange(*queryRange)
+		if err != nil {
+			log.Fatalf("Error parsing range %v: %v", *queryRange, err)
+			return
+		}
+
+		log.Printf("> (Query) Which elves are assigned to any section of %v?", r)
+
+		elves := idx.Overlapping(r)
+
+		for _, a := range elves {
+			log.Printf("Line %d elf %d: %v", a.Group+1, a.Position+1, a.Sections)
+		}
+
+		log.Printf("%d of %d elves are assigned to sections of %v", len(elves), idx.Len(), r)
+	}
+
+	if *crossPairs {
+		log.Printf("> (Camp) Which elves of the whole camp overlap each other?")
+
+		listed := 0
+		idx.OverlappingPairs(func(a ElfAssignment, b ElfAssignment) bool {
+			if listed == *listPairs {
+				return false
+			}
+			listed++
+			log.Printf("Line %d elf %d (%v) overlaps line %d elf %d (%v)", a.Group+1, a.Position+1, a.Sections, b.Group+1, b.Position+1, b.Sections)
+			return true
+		})
+
+		depth, section := idx.MaxDepth()
+
+		log.Printf("Overlapping elves: %d (%d listed)", idx.CountOverlappingPairs(), listed)
+		log.Printf("Most elves on a single section: %d (first at section %d)", depth, section)
+	}
 }
 
-type ElfPair struct {
-	FirstElfSections  []int
-	SecondElfSections []int
-	Overlaps          bool
-	FullyOverlaps     bool
+// The section ranges of a line, one for each elf of the group
+type ElfGroup struct {
+	Sections      []SectionRange
+	Overlaps      bool // any two elves share a section
+	FullyOverlaps bool // the range of an elf fully contains the range of another one
 }
 
-type ElvesPair []ElfPair
+type ElvesGroup []ElfGroup
 
-func (this ElvesPair) ComputeNumberOfFullyOverlappingSections() uint {
+func (this ElvesGroup) ComputeNumberOfFullyOverlappingSections() uint {
 	sum := uint(0)
 
-	for _, pair := range this {
-		if pair.FullyOverlaps {
+	for _, group := range this {
+		if group.FullyOverlaps {
 			sum++
 		}
 	}
@@ -59,11 +210,11 @@ func (this ElvesPair) ComputeNumberOfFullyOverlappingSections() uint {
 
 }
 
-func (this ElvesPair) ComputeNumberOfOverlappingSections() uint {
+func (this ElvesGroup) ComputeNumberOfOverlappingSections() uint {
 	sum := uint(0)
 
-	for _, pair := range this {
-		if pair.Overlaps {
+	for _, group := range this {
+		if group.Overlaps {
 			sum++
 		}
 	}
@@ -72,89 +223,55 @@ func (this ElvesPair) ComputeNumberOfOverlappingSections() uint {
 
 }
 
-func lineToElfPair(l string) ElfPair {
-	elves := strings.Split(l, ",")
-	firstElfSections := sectionRangeToArray(elves[0])
-	secondElfSections := sectionRangeToArray(elves[1])
-	overlapingSections := computeOverlapingSections(firstElfSections, secondElfSections)
-	fullyOverlapingSections := computeFullyOverlapingSections(firstElfSections, secondElfSections)
+func (this ElvesGroup) ComputeNumberOfCommonSectionGroups() uint {
+	sum := uint(0)
 
-	return ElfPair{
-		FirstElfSections:  firstElfSections,
-		SecondElfSections: secondElfSections,
-		Overlaps:          len(overlapingSections) > 0,
-		FullyOverlaps:     len(fullyOverlapingSections) > 0,
+	for _, group := range this {
+		if _, ok := group.CommonSections(); ok {
+			sum++
+		}
 	}
-}
 
-func computeFullyOverlapingSections(firstElfSections []int, secondElfSections []int) []int {
-	fs := firstElfSections[0]
-	fe := firstElfSections[len(firstElfSections)-1]
-	ss := secondElfSections[0]
-	se := secondElfSections[len(secondElfSections)-1]
+	return sum
+}
 
-	var sections []int
+func lineToElfGroup(l string) (ElfGroup, error) {
+	elves := strings.Split(l, ",")
+	sections := make([]SectionRange, len(elves))
 
-	if fs <= ss && fe >= se {
-		sections = secondElfSections
-	} else if fs >= ss && fe <= se {
-		sections = firstElfSections
-	} else {
-		sections = []int{}
+	for i, e := range elves {
+		var err error
+		sections[i], err = parseSectionRange(e)
+		if err != nil {
+			return ElfGroup{}, err
+		}
 	}
 
-	return sections
+	return newElfGroup(sections), nil
 }
 
-func computeOverlapingSections(firstElfSections []int, secondElfSections []int) []int {
-	fs := firstElfSections[0]
-	fe := firstElfSections[len(firstElfSections)-1]
-	ss := secondElfSections[0]
-	se := secondElfSections[len(secondElfSections)-1]
-
-	var sections []int
-
-	if fs <= ss && fe >= se {
-		//fully overlapping
-		sections = secondElfSections
-	} else if fs >= ss && fe <= se {
-		//fully overlapping
-		sections = firstElfSections
-	} else if fs < ss && fe >= ss && fe < se {
-		//overlapping
-		sections = sectionRangeToArray(fmt.Sprintf("%v-%v", ss, fe))
-	} else if fs > ss && fs <= se && fe > se {
-		//overlapping
-		sections = sectionRangeToArray(fmt.Sprintf("%v-%v", fs, se))
-	} else {
-		sections = []int{}
-	}
-
-	return sections
-}
-
-func sectionRangeToArray(sectionRange string) []int {
-	values := strings.Split(sectionRange, "-")
-	start, _ := strconv.Atoi(strings.TrimSpace(values[0]))
-	end, _ := strconv.Atoi(strings.TrimSpace(values[1]))
-	result := make([]int, end-start+1)
-	for i := range result {
-		result[i] = i + start
+func newElfGroup(sections []SectionRange) ElfGroup {
+	return ElfGroup{
+		Sections:      sections,
+		Overlaps:      anyRangesOverlap(sections),
+		FullyOverlaps: anyRangeContainsAnother(sections),
 	}
-	return result
 }
 
-func parseFile(filePath string) (ElvesPair, error) {
+func parseFile(filePath string) (ElvesGroup, error) {
 	lines, err := getFileLines(filePath)
 
 	if err != nil {
 		return nil, err
 	}
 
-	result := make([]ElfPair, len(lines))
+	result := make([]ElfGroup, len(lines))
 
 	for i, line := range lines {
-		result[i] = lineToElfPair(line)
+		result[i], err = lineToElfGroup(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
 	}
 
 	return result, nil
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+const (
+	ReassignmentModeOverlaps     = "overlaps" // no two elves of a group share a section
+	ReassignmentModeFullOverlaps = "full"     // no range of a group contains another one
+	ReassignmentMaxElvesPerGroup = 7          // every order of the elves is tried
+	reassignmentNoCost           = math.MaxUint64
+)
+
+// New ranges for the elves of a group, Changed is the number of sections
+// given to or taken from its elves
+type Reassignment struct {
+	Group   int // 0-based line
+	Before  []SectionRange
+	After   []SectionRange
+	Changed uint64
+}
+
+func reassignmentDescription(mode string) string {
+	if mode == ReassignmentModeFullOverlaps {
+		return "full overlaps"
+	}
+	return mode
+}
+
+// Plans a reassignment for every group with overlaps (or full overlaps, as
+// told by mode), the other groups are left as they are
+func (this ElvesGroup) PlanReassignments(mode string) ([]Reassignment, error) {
+	var result []Reassignment
+
+	for i, group := range this {
+		var affected bool
+		switch mode {
+		case ReassignmentModeOverlaps:
+			affected = group.Overlaps
+		case ReassignmentModeFullOverlaps:
+			affected = group.FullyOverlaps
+		default:
+			return nil, fmt.Errorf("unknown reassignment mode: %v", mode)
+		}
+		if !affected {
+			continue
+		}
+
+		after, changed, err := group.PlanReassignment(mode)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+
+		result = append(result, Reassignment{Group: i, Before: group.Sections, After: after, Changed: changed})
+	}
+
+	return result, nil
+}
+
+// The contiguous ranges closest to the current ones, counting every section
+// an elf gains or loses, without overlaps or without full overlaps.
+//
+// Whatever the mode the new ranges follow some order of the elves where both
+// their starts and their ends increase (and for overlaps every range ends
+// before the next one starts), so for every order a dynamic programming over
+// the candidate start and end sections finds the best ranges. The candidates
+// are the current starts and ends moved by up to the number of elves, as an
+// elf is never pushed further than by all the others
+func (g ElfGroup) PlanReassignment(mode string) ([]SectionRange, uint64, error) {
+	k := len(g.Sections)
+	if k > ReassignmentMaxElvesPerGroup {
+		return nil, 0, fmt.Errorf("groups of more than %d elves are not supported", ReassignmentMaxElvesPerGroup)
+	}
+	if mode != ReassignmentModeOverlaps && mode != ReassignmentModeFullOverlaps {
+		return nil, 0, fmt.Errorf("unknown reassignment mode: %v", mode)
+	}
+
+	candidates := reassignmentCandidates(g.Sections)
+
+	var best []SectionRange
+	bestCost := reassignmentCost{changed: reassignmentNoCost}
+
+	forEachPermutation(k, func(order []int) {
+		after, cost := planReassignmentInOrder(g.Sections, order, candidates, mode)
+		if cost.less(bestCost) {
+			best, bestCost = after, cost
+		}
+	})
+
+	return best, bestCost.changed, nil
+}
+
+// Section IDs start at 1, or lower if the current ranges already do
+func reassignmentCandidates(sections []SectionRange) []int64 {
+	k := int64(len(sections))
+	lowest := int64(1)
+	for _, r := range sections {
+		lowest = minInt64(lowest, r.Start)
+	}
+
+	seen := make(map[int64]bool)
+	var result []int64
+	for _, r := range sections {
+		for _, p := range []int64{r.Start, r.End} {
+			for d := -k; d <= k; d++ {
+				// stay away from the int64 limits
+				if (d > 0 && p > math.MaxInt64-d) || p+d < lowest || seen[p+d] {
+					continue
+				}
+				seen[p+d] = true
+				result = append(result, p+d)
+			}
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
+
+// Sections an elf gains or loses going from a to b
+func changedSections(a SectionRange, b SectionRange) uint64 {
+	if !a.Overlaps(b) {
+		return a.Len() + b.Len()
+	}
+	return uint64(absInt64(a.Start-b.Start)) + uint64(absInt64(a.End-b.End))
+}
+
+func absInt64(a int64) int64 {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+// Among the ranges changing the same number of sections the ones moving their
+// starts and ends the least are kept, e.g. 32-32,31-77 becomes 30-30,31-77
+// rather than 1-1,31-77
+type reassignmentCost struct {
+	changed uint64
+	moved   uint64
+}
+
+func (c reassignmentCost) less(o reassignmentCost) bool {
+	return c.changed < o.changed || (c.changed == o.changed && c.moved < o.moved)
+}
+
+func (c reassignmentCost) add(o reassignmentCost) reassignmentCost {
+	return reassignmentCost{changed: c.changed + o.changed, moved: c.moved + o.moved}
+}
+
+func newReassignmentCost(a SectionRange, b SectionRange) reassignmentCost {
+	return reassignmentCost{
+		changed: changedSections(a, b),
+		moved:   uint64(absInt64(a.Start-b.Start)) + uint64(absInt64(a.End-b.End)),
+	}
+}
+
+// levels[i][x][y] is the cheapest assignment of the first i+1 elves of the
+// order with the last one on candidates[x]-candidates[y]
+type reassignmentCell struct {
+	cost   reassignmentCost
+	parent int // x*p+y of the previous elf, -1 for the first one
+}
+
+func planReassignmentInOrder(sections []SectionRange, order []int, candidates []int64, mode string) ([]SectionRange, reassignmentCost) {
+	p := len(candidates)
+	levels := make([][][]reassignmentCell, len(order))
+
+	for i, elf := range order {
+		level := make([][]reassignmentCell, p)
+		for x := range level {
+			level[x] = make([]reassignmentCell, p)
+			for y := range level[x] {
+				level[x][y] = reassignmentCell{cost: reassignmentCost{changed: reassignmentNoCost}, parent: -1}
+			}
+		}
+
+		var prefix [][]int
+		if i > 0 {
+			prefix = reassignmentPrefix(levels[i-1], mode)
+		}
+
+		for x := 0; x < p; x++ {
+			for y := x; y < p; y++ {
+				cost := newReassignmentCost(sections[elf], SectionRange{Start: candidates[x], End: candidates[y]})
+				parent := -1
+
+				if i > 0 {
+					var previous int
+					switch mode {
+					case ReassignmentModeOverlaps:
+						previous = x - 1
+						if previous < 0 {
+							continue
+						}
+						previous = prefix[0][previous]
+					case ReassignmentModeFullOverlaps:
+						if x == 0 || y == 0 {
+							continue
+						}
+						previous = prefix[x-1][y-1]
+					}
+					if previous < 0 || levels[i-1][previous/p][previous%p].cost.changed == reassignmentNoCost {
+						continue
+					}
+					cost = cost.add(levels[i-1][previous/p][previous%p].cost)
+					parent = previous
+				}
+
+				level[x][y] = reassignmentCell{cost: cost, parent: parent}
+			}
+		}
+
+		levels[i] = level
+	}
+
+	last := levels[len(order)-1]
+	best := -1
+	for x := 0; x < p; x++ {
+		for y := x; y < p; y++ {
+			if last[x][y].cost.changed != reassignmentNoCost && (best < 0 || last[x][y].cost.less(last[best/p][best%p].cost)) {
+				best = x*p + y
+			}
+		}
+	}
+	if best < 0 {
+		return nil, reassignmentCost{changed: reassignmentNoCost}
+	}
+
+	result := make([]SectionRange, len(order))
+	cost := last[best/p][best%p].cost
+	for i := len(order) - 1; i >= 0; i-- {
+		x, y := best/p, best%p
+		result[order[i]] = SectionRange{Start: candidates[x], End: candidates[y]}
+		best = levels[i][x][y].parent
+	}
+
+	return result, cost
+}
+
+// Where the previous elf may be at its cheapest: for overlaps prefix[0][y] is
+// the best range ending at or before candidates[y], for full overlaps
+// prefix[x][y] the best one starting and ending at or before them. -1 when
+// there is none
+func reassignmentPrefix(previous [][]reassignmentCell, mode string) [][]int {
+	p := len(previous)
+
+	better := func(a int, b int) int {
+		if a < 0 {
+			return b
+		}
+		if b < 0 || !previous[b/p][b%p].cost.less(previous[a/p][a%p].cost) {
+			return a
+		}
+		return b
+	}
+
+	if mode == ReassignmentModeOverlaps {
+		prefix := [][]int{make([]int, p)}
+		for y := 0; y < p; y++ {
+			best := -1
+			if y > 0 {
+				best = prefix[0][y-1]
+			}
+			for x := 0; x <= y; x++ {
+				best = better(best, x*p+y)
+			}
+			prefix[0][y] = best
+		}
+		return prefix
+	}
+
+	prefix := make([][]int, p)
+	for x := 0; x < p; x++ {
+		prefix[x] = make([]int, p)
+		for y := 0; y < p; y++ {
+			best := -1
+			if y >= x {
+				best = x*p + y
+			}
+			if x > 0 {
+				best = better(best, prefix[x-1][y])
+			}
+			if y > 0 {
+				best = better(best, prefix[x][y-1])
+			}
+			prefix[x][y] = best
+		}
+	}
+	return prefix
+}
+
+// Calls visit with every order of 0..n-1, in lexicographic order
+func forEachPermutation(n int, visit func(order []int)) {
+	order := make([]int, n)
+	used := make([]bool, n)
+
+	var permute func(i int)
+	permute = func(i int) {
+		if i == n {
+			visit(order)
+			return
+		}
+		for v := 0; v < n; v++ {
+			if used[v] {
+				continue
+			}
+			used[v] = true
+			order[i] = v
+			permute(i + 1)
+			used[v] = false
+		}
+	}
+
+	permute(0)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The closed range of section IDs Start-End assigned to an elf
+type SectionRange struct {
+	Start int64
+	End   int64
+}
+
+// Number of sections of the range
+func (r SectionRange) Len() uint64 {
+	return uint64(r.End-r.Start) + 1
+}
+
+func (r SectionRange) ContainsSection(s int64) bool {
+	return r.Start <= s && s <= r.End
+}
+
+// Every section of o is also in r
+func (r SectionRange) Contains(o SectionRange) bool {
+	return r.Start <= o.Start && o.End <= r.End
+}
+
+func (r SectionRange) Overlaps(o SectionRange) bool {
+	return r.Start <= o.End && o.Start <= r.End
+}
+
+// The sections in both ranges, false when they do not overlap
+func (r SectionRange) Intersection(o SectionRange) (SectionRange, bool) {
+	result := SectionRange{Start: maxInt64(r.Start, o.Start), End: minInt64(r.End, o.End)}
+	return result, result.Start <= result.End
+}
+
+func (r SectionRange) String() string {
+	return fmt.Sprintf("%d-%d", r.Start, r.End)
+}
+
+func parseSectionRange(s string) (SectionRange, error) {
+	values := strings.Split(s, "-")
+	if len(values) != 2 {
+		return SectionRange{}, fmt.Errorf("expected a range like 2-4 in %q", s)
+	}
+
+	start, err := strconv.ParseInt(strings.TrimSpace(values[0]), 10, 64)
+	if err != nil {
+		return SectionRange{}, err
+	}
+	end, err := strconv.ParseInt(strings.TrimSpace(values[1]), 10, 64)
+	if err != nil {
+		return SectionRange{}, err
+	}
+	if end < start {
+		return SectionRange{}, fmt.Errorf("range %q ends before it starts", s)
+	}
+
+	return SectionRange{Start: start, End: end}, nil
+}
+
+func minInt64(a int64, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt64(a int64, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,165 @@
+package main
+
+import "fmt"
+
+// A crane following the moves of the rearrangement procedure, Apply returns
+// the number of trips (lifts from a stack to another) the move took
+type CrateMover interface {
+	Name() string
+	Apply(stacks map[int]Crates, m Move) (int, error)
+}
+
+// Lifts one crate at a time, so the moved crates end up in reverse order
+type CrateMover9000 struct{}
+
+// Lifts all the moved crates at once, keeping their order
+type CrateMover9001 struct{}
+
+// Lifts up to Capacity crates at a time, each lift keeping their order
+type CapacityCrateMover struct {
+	Capacity int
+}
+
+// Only reaches the stacks next to the one it stands over, so every load Lift
+// would carry straight to the destination is carried one stack at a time
+// through the stacks in between. The crates end up as with Lift, each of its
+// trips taking one trip per stack crossed
+type AdjacentCrateMover struct {
+	Lift CrateMover
+}
+
+// The cranes compared by the equipment report, capacity is the one of the
+// capacity-limited crane
+func CrateMovers(capacity int) []CrateMover {
+	return []CrateMover{
+		CrateMover9000{},
+		CrateMover9001{},
+		CapacityCrateMover{Capacity: capacity},
+		AdjacentCrateMover{Lift: CrateMover9000{}},
+		AdjacentCrateMover{Lift: CrateMover9001{}},
+	}
+}
+
+func (CrateMover9000) Name() string { return "CrateMover 9000" }
+
+// Lifting the crates one by one leaves them as lifting them all at once and
+// turning them upside down, without shifting the other crate count times
+func (CrateMover9000) Apply(stacks map[int]Crates, m Move) (int, error) {
+	if moves, err := checkMove(stacks, m); !moves {
+		return 0, err
+	}
+	liftCrates(stacks, m.From, m.To, m.Count)
+
+	moved := stacks[m.To][:m.Count]
+	for i, j := 0, len(moved)-1; i < j; i, j = i+1, j-1 {
+		moved[i], moved[j] = moved[j], moved[i]
+	}
+
+	return m.Count, nil
+}
+
+func (CrateMover9001) Name() string { return "CrateMover 9001" }
+
+func (CrateMover9001) Apply(stacks map[int]Crates, m Move) (int, error) {
+	if moves, err := checkMove(stacks, m); !moves {
+		return 0, err
+	}
+	liftCrates(stacks, m.From, m.To, m.Count)
+
+	return 1, nil
+}
+
+func (c CapacityCrateMover) Name() string {
+	return fmt.Sprintf("CrateMover with capacity %d", c.Capacity)
+}
+
+func (c CapacityCrateMover) Apply(stacks map[int]Crates, m Move) (int, error) {
+	if c.Capacity < 1 {
+		return 0, fmt.Errorf("invalid crane capacity %d", c.Capacity)
+	}
+	if moves, err := checkMove(stacks, m); !moves {
+		return 0, err
+	}
+
+	trips := 0
+	for left := m.Count; left > 0; left -= c.Capacity {
+		liftCrates(stacks, m.From, m.To, minInt(left, c.Capacity))
+		trips++
+	}
+
+	return trips, nil
+}
+
+func (c AdjacentCrateMover) Name() string {
+	return fmt.Sprintf("Adjacent %v", c.Lift.Name())
+}
+
+func (c AdjacentCrateMover) Apply(stacks map[int]Crates, m Move) (int, error) {
+	if moves, err := checkMove(stacks, m); !moves {
+		return 0, err
+	}
+
+	step := 1
+	if m.To < m.From {
+		step = -1
+	}
+
+	hops := 0
+	for s := m.From; s != m.To; s += step {
+		if _, ok := stacks[s+step]; !ok {
+			return 0, fmt.Errorf("no stack %d between %d and %d", s+step, m.From, m.To)
+		}
+		hops++
+	}
+
+	// a load set down on a stack in between is lifted again right away, so
+	// those stacks are left as they were
+	trips, err := c.Lift.Apply(stacks, m)
+	if err != nil {
+		return 0, err
+	}
+
+	return trips * hops, nil
+}
+
+// False when the move is invalid, or when it leaves the crates where they are
+// (no crates or the same stack) so that no crane takes a trip for it
+func checkMove(stacks map[int]Crates, m Move) (bool, error) {
+	from, ok := stacks[m.From]
+	if !ok {
+		return false, fmt.Errorf("no stack %d", m.From)
+	}
+	if _, ok := stacks[m.To]; !ok {
+		return false, fmt.Errorf("no stack %d", m.To)
+	}
+	if m.Count < 0 || m.Count > len(from) {
+		return false, fmt.Errorf("cannot move %d crates from stack %d with %d crates", m.Count, m.From, len(from))
+	}
+	return m.Count > 0 && m.From != m.To, nil
+}
+
+// Moves the top count crates of a stack on top of another one, keeping their
+// order
+func liftCrates(stacks map[int]Crates, from int, to int, count int) {
+	// The moved crates are copied, a slice of the origin stack would be
+	// overwritten when appending to it
+	cratesToMove := make(Crates, count, count+len(stacks[to]))
+	copy(cratesToMove, stacks[from][:count])
+
+	stacks[to] = append(cratesToMove, stacks[to]...)
+	stacks[from] = stacks[from][count:]
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
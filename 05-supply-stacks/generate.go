@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const CrateLabelLetters = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// A random rearrangement of stacksCount stacks of up to maxHeight crates with
+// labels of labelLength letters and movesCount valid moves
+func generateRearrangement(stacksCount int, labelLength int, maxHeight int, movesCount int, random *rand.Rand) Rearrangement {
+	r := Rearrangement{CratesStack: make(map[int]Crates, stacksCount)}
+
+	for n := 1; n <= stacksCount; n++ {
+		crates := make(Crates, random.Intn(maxHeight+1))
+		for i := range crates {
+			label := make([]byte, labelLength)
+			for j := range label {
+				label[j] = CrateLabelLetters[random.Intn(len(CrateLabelLetters))]
+			}
+			crates[i] = string(label)
+		}
+		r.CratesStack[n] = crates
+	}
+
+	// the moves are played with a 9001 so that every one finds enough crates,
+	// the 9000 moves the same number of crates from the same stacks
+	stacks := r.Copy().CratesStack
+	for len(r.Moves) < movesCount && stacksCount > 1 {
+		m := Move{From: 1 + random.Intn(stacksCount), To: 1 + random.Intn(stacksCount)}
+		if m.From == m.To || len(stacks[m.From]) == 0 {
+			continue
+		}
+		m.Count = 1 + random.Intn(len(stacks[m.From]))
+		CrateMover9001{}.Apply(stacks, m)
+		r.Moves = append(r.Moves, m)
+	}
+
+	return r
+}
+
+// The rearrangement in the puzzle format, every column as wide as the widest
+// crate or stack number
+func rearrangementToLines(r Rearrangement) []string {
+	stacks := r.StackNumbers()
+	width, height := 0, 0
+	for _, n := range stacks {
+		width = maxInt(width, len(strconv.Itoa(n)))
+		for _, c := range r.CratesStack[n] {
+			width = maxInt(width, len(c)+2)
+		}
+		height = maxInt(height, len(r.CratesStack[n]))
+	}
+
+	var lines []string
+	for level := height; level > 0; level-- {
+		columns := make([]string, len(stacks))
+		for i, n := range stacks {
+			crates := r.CratesStack[n]
+			column := ""
+			if len(crates) >= level {
+				column = "[" + crates[len(crates)-level] + "]"
+			}
+			columns[i] = column + strings.Repeat(" ", width-len(column))
+		}
+		lines = append(lines, strings.TrimRight(strings.Join(columns, " "), " "))
+	}
+
+	columns := make([]string, len(stacks))
+	for i, n := range stacks {
+		number := strconv.Itoa(n)
+		left := (width - len(number)) / 2
+		columns[i] = strings.Repeat(" ", left) + number + strings.Repeat(" ", width-left-len(number))
+	}
+	lines = append(lines, strings.Join(columns, " "), "")
+
+	for _, m := range r.Moves {
+		lines = append(lines, fmt.Sprintf("move %d from %d to %d", m.Count, m.From, m.To))
+	}
+
+	return lines
+}
+
+// Writes a generated rearrangement in the puzzle format and parses it back,
+// checking it reads the same stacks and moves, then follows it with both
+// cranes of the puzzle
+func runGenerated(stacksCount int, labelLength int, maxHeight int, movesCount int, seed int64) error {
+	generated := generateRearrangement(stacksCount, labelLength, maxHeight, movesCount, rand.New(rand.NewSource(seed)))
+	lines := rearrangementToLines(generated)
+
+	log.Printf("> (Generated) %d stacks of up to %d crates labelled with %d letters and %d moves (%d lines)", stacksCount, maxHeight, labelLength, len(generated.Moves), len(lines))
+
+	start := time.Now()
+	parsed, err := linesToRearrangement(lines)
+	if err != nil {
+		return err
+	}
+	log.Printf("Parsed - %s", time.Since(start))
+
+	if !reflect.DeepEqual(parsed.CratesStack, generated.CratesStack) {
+		return fmt.Errorf("the parsed stacks differ from the generated ones")
+	}
+	if !reflect.DeepEqual(parsed.Moves, generated.Moves) {
+		return fmt.Errorf("the parsed moves differ from the generated ones")
+	}
+
+	for _, cm := range []CrateMover{CrateMover9000{}, CrateMover9001{}} {
+		r := parsed.Copy()
+		start = time.Now()
+
+		trips, err := r.ProcessRearrangement(cm)
+		if err != nil {
+			return fmt.Errorf("%v: %v", cm.Name(), err)
+		}
+
+		empty := 0
+		for _, c := range r.TopCrates() {
+			if c == EmptyStackTopCrate {
+				empty++
+			}
+		}
+
+		log.Printf("%v: %d trips, %d top crates and %d empty stacks - %s", cm.Name(), trips, len(r.CratesStack)-empty, empty, time.Since(start))
+	}
+
+	log.Printf("The parsed diagram and moves match the generated ones")
+
+	return nil
+}
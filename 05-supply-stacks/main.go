@@ -8,14 +8,33 @@ import (
 	"log"
 	"os"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
 
 func main() {
 	var inputFilePath = flag.String("inputFilePath", "./inputf.txt", "Input File")
+	var compareCranes = flag.Bool("compareCranes", false, "Compare the trips every crane takes to follow the procedure")
+	var craneCapacity = flag.Int("craneCapacity", 3, "Most crates the capacity-limited crane lifts at a time")
+	var generate = flag.Int("generate", 0, "Check the parser on a generated diagram with this many stacks instead of solving the input")
+	var generateLabelLength = flag.Int("generateLabelLength", 2, "Letters of every generated crate label")
+	var generateHeight = flag.Int("generateHeight", 50, "Most crates of every generated stack")
+	var generateMoves = flag.Int("generateMoves", 10000, "Moves of the generated procedure")
+	var seed = flag.Int64("seed", 1, "Seed of the generated diagram")
 	flag.Parse()
 
+	if *generate > 0 {
+		if *generateLabelLength < 1 {
+			log.Fatalf("Invalid label length %d", *generateLabelLength)
+			return
+		}
+		if err := runGenerated(*generate, *generateLabelLength, *generateHeight, *generateMoves, *seed); err != nil {
+			log.Fatalf("Error checking generated diagram: %v", err)
+		}
+		return
+	}
+
 	log.Printf("inputFilePath %v\n", *inputFilePath)
 
 	log.Printf("> (1st Puzzle) After the rearrangement procedure completes, what crate ends up on top of each stack (Mover 9000)?")
@@ -29,7 +48,10 @@ func main() {
 
 	rearrangementWithMover9000 := rearrangement.Copy()
 
-	rearrangementWithMover9000.ProcessRearrangementWithCrateMover9000()
+	if _, err := rearrangementWithMover9000.ProcessRearrangement(CrateMover9000{}); err != nil {
+		log.Fatalf("Error rearranging crates: %v", err)
+		return
+	}
 	topCratesOfStacksWithMover9000 := rearrangementWithMover9000.GetTopCratesStacks()
 
 	log.Printf("The crates that end up on top of each stack are: %v", topCratesOfStacksWithMover9000)
@@ -38,14 +60,35 @@ func main() {
 
 	rearrangementWithMover9001 := rearrangement.Copy()
 
-	rearrangementWithMover9001.ProcessRearrangementWithCrateMover9001()
+	if _, err := rearrangementWithMover9001.ProcessRearrangement(CrateMover9001{}); err != nil {
+		log.Fatalf("Error rearranging crates: %v", err)
+		return
+	}
 	topCratesOfStacksWithMover9001 := rearrangementWithMover9001.GetTopCratesStacks()
 
 	log.Printf("The crates that end up on top of each stack are: %v", topCratesOfStacksWithMover9001)
+
+	if *compareCranes {
+		log.Printf("> (Equipment) How many trips does every crane take to follow the procedure, and what ends up on top of each stack?")
+
+		for _, cm := range CrateMovers(*craneCapacity) {
+			r := rearrangement.Copy()
+
+			trips, err := r.ProcessRearrangement(cm)
+
+			if err != nil {
+				log.Printf("%v: %v", cm.Name(), err)
+				continue
+			}
+
+			log.Printf("%v: %d trips, top crates %v", cm.Name(), trips, r.GetTopCratesStacks())
+		}
+	}
 }
 
 const (
-	MovesRegularExpression string = `move (.*?) from (.*?) to (.?)`
+	MovesRegularExpression string = `^move (\d+) from (\d+) to (\d+)$`
+	EmptyStackTopCrate     string = "-"
 )
 
 type Rearrangement struct {
@@ -54,7 +97,7 @@ type Rearrangement struct {
 	IsRearranged bool
 }
 
-type Crates []byte //byte - crate id
+type Crates []string //string - crate label, the top crate first
 
 type Move struct {
 	// move {{Count}} from {{From}} to {{To}}
@@ -63,59 +106,62 @@ type Move struct {
 	To    int
 }
 
-func (r Rearrangement) GetTopCratesStacks() string {
-	var result string
-	cratesRange := len(r.CratesStack) + 1
+// The label of the top crate of every stack in stack order,
+// EmptyStackTopCrate for the empty ones
+func (r Rearrangement) TopCrates() []string {
+	stacks := r.StackNumbers()
+	result := make([]string, len(stacks))
 
-	for i := 1; i < cratesRange; i++ {
-		crate := r.CratesStack[i][0]
-		result += fmt.Sprintf("%c", crate)
+	for i, n := range stacks {
+		result[i] = EmptyStackTopCrate
+		if crates := r.CratesStack[n]; len(crates) > 0 {
+			result[i] = crates[0]
+		}
 	}
 
 	return result
 }
 
-func (r Rearrangement) ProcessRearrangementWithCrateMover9000() {
-	for _, move := range r.Moves {
-		for i := 0; i < move.Count; i++ {
-			crates := r.CratesStack[move.From]
-			value := crates[0]
-			r.CratesStack[move.From] = crates[1:]
-			r.CratesStack[move.To] = append([]byte{value}, r.CratesStack[move.To]...)
+// The top crates one after the other like the puzzle answer, separated by
+// spaces when a label is longer than a letter so that every stack can be told
+// apart
+func (r Rearrangement) GetTopCratesStacks() string {
+	topCrates := r.TopCrates()
+
+	separator := ""
+	for _, c := range topCrates {
+		if len(c) != 1 {
+			separator = " "
 		}
 	}
 
-	r.IsRearranged = true
+	return strings.Join(topCrates, separator)
 }
 
-func (r Rearrangement) ProcessRearrangementWithCrateMover9001() {
-	for _, move := range r.Moves {
-		orgCrates := r.CratesStack[move.From]
-		dstCrates := r.CratesStack[move.To]
-
-		// In Go, append can either me mutable or immutable
-		// A slice of a slice ([:...]) is a view of the sliced slice
-		// Hence we need to create a copy of the moving crates, else we will
-		// end up swapping both crates stacks
-		// Example:
-		// a := []int {1, 2, 3}
-		// b := a[2:]
-		// b[0] = 5
-		// Slice a is now = [1, 2 ,5]
-
-		cratesToMove := orgCrates[:move.Count]
-		cratesToMoveCopy := make(Crates, len(cratesToMove))
-		copy(cratesToMoveCopy, cratesToMove)
-
-		dstCrates = append(cratesToMoveCopy, dstCrates...)
-		orgCrates = orgCrates[move.Count:]
+func (r Rearrangement) StackNumbers() []int {
+	result := make([]int, 0, len(r.CratesStack))
+	for n := range r.CratesStack {
+		result = append(result, n)
+	}
+	sort.Ints(result)
+	return result
+}
 
-		r.CratesStack[move.From] = orgCrates
-		r.CratesStack[move.To] = dstCrates
+// Follows every move with the crane, returns the number of trips it took
+func (r *Rearrangement) ProcessRearrangement(cm CrateMover) (int, error) {
+	trips := 0
 
+	for i, move := range r.Moves {
+		t, err := cm.Apply(r.CratesStack, move)
+		if err != nil {
+			return trips, fmt.Errorf("move %d: %v", i+1, err)
+		}
+		trips += t
 	}
 
 	r.IsRearranged = true
+
+	return trips, nil
 }
 
 func (r Rearrangement) Copy() Rearrangement {
@@ -138,47 +184,131 @@ func (r Rearrangement) Copy() Rearrangement {
 	}
 }
 
-func linesToCratesStack(lines []string) map[int]Crates {
+// A crate label or stack number and the columns it spans in its line
+type diagramToken struct {
+	Text  string
+	Start int
+	End   int
+}
+
+// The last line of the diagram numbers the stacks, every crate belongs to
+// the stack whose number shares the most columns with it, so stacks may have
+// several digits and crates labels of any length like [AB]
+func linesToCratesStack(lines []string) (map[int]Crates, error) {
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("missing stack numbers line")
+	}
+
 	indexOfLineOfStacksIds := len(lines) - 1
 	result := make(map[int]Crates)
-	lineStacksNumbers := lines[indexOfLineOfStacksIds]
-	stacksNumbers := strings.Split(lineStacksNumbers, " ")
 
-	for _, stackNumberString := range stacksNumbers {
+	stacksNumbers := fieldsWithColumns(lines[indexOfLineOfStacksIds])
+	stacks := make([]int, len(stacksNumbers))
 
-		if strings.TrimSpace(stackNumberString) == "" {
-			continue
+	for i, t := range stacksNumbers {
+		stackNumber, err := strconv.Atoi(t.Text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid stack number %q", indexOfLineOfStacksIds+1, t.Text)
+		}
+		if _, ok := result[stackNumber]; ok {
+			return nil, fmt.Errorf("line %d: duplicated stack %d", indexOfLineOfStacksIds+1, stackNumber)
 		}
+		stacks[i] = stackNumber
+		result[stackNumber] = Crates{}
+	}
+
+	for i := 0; i < indexOfLineOfStacksIds; i++ {
+		crates, err := lineToCrates(lines[i])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+
+		for _, c := range crates {
+			best, bestColumns := -1, 0
+			for j, t := range stacksNumbers {
+				if columns := minInt(c.End, t.End) - maxInt(c.Start, t.Start) + 1; columns > bestColumns {
+					best, bestColumns = j, columns
+				}
+			}
+			if best < 0 {
+				return nil, fmt.Errorf("line %d: crate [%v] at column %d is not above any stack", i+1, c.Text, c.Start+1)
+			}
+			result[stacks[best]] = append(result[stacks[best]], c.Text)
+		}
+	}
+
+	return result, nil
+}
 
-		index := strings.Index(lineStacksNumbers, stackNumberString)
-		stackNumber, _ := strconv.Atoi(stackNumberString)
+// The crates of a diagram line, Start and End are the columns of the brackets
+func lineToCrates(l string) ([]diagramToken, error) {
+	var result []diagramToken
 
-		var crates []byte
-		for i := 0; i < indexOfLineOfStacksIds; i++ {
-			stacksIds := lines[i][index]
-			if string(stacksIds) != " " {
-				crates = append(crates, stacksIds)
+	for i := 0; i < len(l); i++ {
+		switch l[i] {
+		case ' ':
+			continue
+		case '[':
+			end := strings.IndexByte(l[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed crate at column %d", i+1)
+			}
+			if end == 1 {
+				return nil, fmt.Errorf("crate without label at column %d", i+1)
 			}
+			result = append(result, diagramToken{Text: l[i+1 : i+end], Start: i, End: i + end})
+			i += end
+		default:
+			return nil, fmt.Errorf("unexpected %q at column %d", l[i], i+1)
 		}
+	}
 
-		result[stackNumber] = crates
+	return result, nil
+}
+
+func fieldsWithColumns(l string) []diagramToken {
+	var result []diagramToken
+
+	for i := 0; i < len(l); i++ {
+		if l[i] == ' ' {
+			continue
+		}
+		start := i
+		for i < len(l) && l[i] != ' ' {
+			i++
+		}
+		result = append(result, diagramToken{Text: l[start:i], Start: start, End: i - 1})
 	}
+
 	return result
 }
 
-func lineToMove(l string) Move {
-	re := regexp.MustCompile(MovesRegularExpression)
-	match := re.FindStringSubmatch(l)
+var movesRegularExpression = regexp.MustCompile(MovesRegularExpression)
 
-	count, _ := strconv.Atoi(match[1])
-	from, _ := strconv.Atoi(match[2])
-	to, _ := strconv.Atoi(match[3])
+func lineToMove(l string) (Move, error) {
+	match := movesRegularExpression.FindStringSubmatch(strings.TrimSpace(l))
+	if match == nil {
+		return Move{}, fmt.Errorf("expected a move like \"move 1 from 2 to 3\" in %q", l)
+	}
+
+	count, err := strconv.Atoi(match[1])
+	if err != nil {
+		return Move{}, err
+	}
+	from, err := strconv.Atoi(match[2])
+	if err != nil {
+		return Move{}, err
+	}
+	to, err := strconv.Atoi(match[3])
+	if err != nil {
+		return Move{}, err
+	}
 
 	return Move{
 		Count: count,
 		From:  from,
 		To:    to,
-	}
+	}, nil
 }
 
 func parseFile(filePath string) (Rearrangement, error) {
@@ -188,6 +318,10 @@ func parseFile(filePath string) (Rearrangement, error) {
 		return Rearrangement{}, err
 	}
 
+	return linesToRearrangement(lines)
+}
+
+func linesToRearrangement(lines []string) (Rearrangement, error) {
 	var cratesStackLines []string
 	lineNumber := 0
 	lineCount := len(lines)
@@ -203,13 +337,19 @@ func parseFile(filePath string) (Rearrangement, error) {
 		cratesStackLines = append(cratesStackLines, line)
 	}
 
-	cratesStack := linesToCratesStack(cratesStackLines)
+	cratesStack, err := linesToCratesStack(cratesStackLines)
+	if err != nil {
+		return Rearrangement{}, err
+	}
 
 	numberMoves := lineCount - lineNumber
 	moves := make([]Move, lineCount-lineNumber)
 
 	for i := 0; i < numberMoves; i++ {
-		moves[i] = lineToMove(lines[i+lineNumber])
+		moves[i], err = lineToMove(lines[i+lineNumber])
+		if err != nil {
+			return Rearrangement{}, fmt.Errorf("line %d: %v", i+lineNumber+1, err)
+		}
 	}
 
 	return Rearrangement{